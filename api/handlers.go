@@ -9,17 +9,36 @@ import (
 	"time"
 
 	"github.com/profclems/compozify/pkg/parser"
+	"github.com/profclems/compozify/pkg/render"
 )
 
 // Response is the response body for the ParseDockerCommand handler.
 type Response struct {
+	// Output holds the rendered files, each preceded by a comment naming it when there are several.
 	Output string `json:"output"`
+	// Files are the rendered files when the format renders more than one file.
+	Files []File `json:"files,omitempty"`
+}
+
+// File is a file rendered by the ParseDockerCommand handler.
+type File struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
 }
 
 // ParseDockerCommand parses a Docker command and returns the equivalent Docker Compose YAML.
 func (server *Server) ParseDockerCommand(w http.ResponseWriter, r *http.Request) {
 	type DockerCommand struct {
-		Command string `json:"command"`
+		Command     string `json:"command"`
+		ServiceName string `json:"serviceName"`
+		// Target is the docker compose file format version. An empty target omits the version.
+		Target *string `json:"target"`
+		Strict bool    `json:"strict"`
+		Indent int     `json:"indent"`
+		// Annotate comments the attributes with the docker flags they were converted from.
+		Annotate bool `json:"annotate"`
+		// Format is the output format. Defaults to docker compose.
+		Format string `json:"format"`
 	}
 
 	var dockerCmd DockerCommand
@@ -53,8 +72,29 @@ func (server *Server) ParseDockerCommand(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	if dockerCmd.Format == "" {
+		dockerCmd.Format = render.DefaultFormat
+	}
+	if dockerCmd.Annotate && dockerCmd.Format != render.DefaultFormat {
+		errorMsg = fmt.Sprintf("Annotate can only be used with the %s format", render.DefaultFormat)
+		code = http.StatusBadRequest
+		return
+	}
+
+	opts := []parser.Option{
+		parser.WithServiceName(dockerCmd.ServiceName),
+		parser.WithStrict(dockerCmd.Strict),
+		parser.WithAnnotate(dockerCmd.Annotate),
+	}
+	if dockerCmd.Target != nil {
+		opts = append(opts, parser.WithTarget(*dockerCmd.Target))
+	}
+	if dockerCmd.Indent != 0 {
+		opts = append(opts, parser.WithIndent(dockerCmd.Indent))
+	}
+
 	// Create a new Parser
-	p, err := parser.New(dockerCmd.Command)
+	p, err := parser.New(dockerCmd.Command, opts...)
 	if err != nil {
 		errorMsg = fmt.Sprintf("Error creating parser: %v", err)
 		code = http.StatusBadRequest
@@ -69,11 +109,19 @@ func (server *Server) ParseDockerCommand(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	dockerComposeYaml := p.String()
+	files, err := render.Render(dockerCmd.Format, p)
+	if err != nil {
+		errorMsg = fmt.Sprintf("Error rendering Docker command: %v", err)
+		code = http.StatusBadRequest
+		return
+	}
 
 	// Create the response
-	resp := Response{
-		Output: dockerComposeYaml,
+	resp := Response{Output: string(render.Concat(files))}
+	if len(files) > 1 {
+		for _, file := range files {
+			resp.Files = append(resp.Files, File{Name: file.Name, Content: string(file.Data)})
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -84,6 +132,30 @@ func (server *Server) ParseDockerCommand(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// ListFlags returns the docker run flags known to the parser, or the docker service create flags
+// with ?command=service-create, with their type, aliases, compose path and status.
+func (server *Server) ListFlags(w http.ResponseWriter, r *http.Request) {
+	logger := server.logger.With().Str("handler", "ListFlags").Str("remoteAddr", r.RemoteAddr).Logger()
+	logger.Info().Msgf("%s %s %s", r.Method, r.URL.Path, r.Proto)
+
+	var flags []parser.FlagInfo
+	switch command := r.URL.Query().Get("command"); command {
+	case "", "run":
+		flags = parser.KnownFlags()
+	case "service-create":
+		flags = parser.KnownServiceCreateFlags()
+	default:
+		logger.Error().Msgf("Unknown docker command %q", command)
+		http.Error(w, fmt.Sprintf("Unknown docker command %q", command), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(flags); err != nil {
+		logger.Err(err).Msg("Unable to write response")
+	}
+}
+
 // appHandler is web app http handler function.
 func (server *Server) appHandler(w http.ResponseWriter, r *http.Request) {
 	staticServer := http.FileServer(http.FS(server.assets))
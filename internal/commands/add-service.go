@@ -2,7 +2,6 @@ package commands
 
 import (
 	"os"
-	"strings"
 
 	"github.com/rs/zerolog"
 	"github.com/spf13/cobra"
@@ -11,12 +10,16 @@ import (
 )
 
 type addServiceOpts struct {
+	parserFlags
+	commandInput
+
 	Logger *zerolog.Logger
 
-	File        string
-	Command     string
-	Write       bool
-	ServiceName string
+	File          string
+	Command       string
+	Write         bool
+	ServiceName   string
+	ExtendsAnchor string
 }
 
 func newAddServiceCmd(logger *zerolog.Logger) *cobra.Command {
@@ -24,12 +27,15 @@ func newAddServiceCmd(logger *zerolog.Logger) *cobra.Command {
 		Logger: logger,
 	}
 	cmd := &cobra.Command{
-		Use:   "add-service [flags] DOCKER_RUN_COMMAND",
+		Use:   "add-service [flags] DOCKER_RUN_COMMAND | -",
 		Short: "Add a service to an existing docker-compose file",
 		Long: `Converts the docker run command to docker compose and adds as a new service to an existing docker-compose file.
 If no file is specified, compozify will look for a docker compose file in the current directory.
 If no file is found, compozify will create one in the current directory.
 Expected file names are docker-compose.[yml,yaml], compose.[yml,yaml]
+The docker run command is read from stdin with "-" or from a file with --from-file,
+where it may span multiple lines continued with a trailing backslash.
+The input must hold a single command, use extract to convert the docker commands of a script.
 `,
 		Example: `
 # add service to existing docker-compose file in current directory
@@ -46,25 +52,33 @@ $ compozify add-service -w -f /path/to/docker-compose.yml -- docker run -i -t --
 
 # add service with custom name
 $ compozify add-service -w -f /path/to/docker-compose.yml -n my-service "docker run -i -t --rm alpine"
+
+# add service extending the defaults defined with "x-common: &common" using "<<: *common"
+$ compozify add-service -w --extends-anchor common "docker run -i -t --rm alpine"
+
+# add service from a docker run command saved in a file
+$ compozify add-service -w --from-file run.sh
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			switch len(args) {
-			case 0:
+			if len(args) == 0 && opts.FromFile == "" {
 				return cmd.Help()
-			case 1:
-				opts.Command = args[0]
-			default:
-				opts.Command = strings.Join(args, " ")
 			}
+			command, err := opts.command(args, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			opts.Command = command
 
 			return addServiceRun(&opts)
 		},
-		Args: cobra.MinimumNArgs(1),
 	}
 
 	cmd.Flags().StringVarP(&opts.ServiceName, "service-name", "n", "", "Name of the service")
 	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "write to file")
 	cmd.Flags().StringVarP(&opts.File, "file", "f", "", "Compose file path")
+	cmd.Flags().StringVar(&opts.ExtendsAnchor, "extends-anchor", "", "Anchor of the compose file the service extends with a merge key")
+	opts.commandInput.addFlags(cmd.Flags())
+	opts.parserFlags.addFlags(cmd.Flags())
 
 	return cmd
 }
@@ -72,22 +86,10 @@ $ compozify add-service -w -f /path/to/docker-compose.yml -n my-service "docker
 func addServiceRun(opts *addServiceOpts) error {
 	readFile := opts.File != ""
 	if opts.File == "" {
-		opts.Logger.Info().Msg("No compose file specified. Searching for compose file in current directory")
-		expectedFiles := []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
-
-		for _, file := range expectedFiles {
-			if _, err := os.Stat(file); err == nil {
-				opts.File = file
-				readFile = true
-				opts.Logger.Info().Msgf("Found compose file: %s", file)
-				break
-			}
-		}
-
-		if opts.File == "" {
+		opts.File, readFile = findComposeFile(opts.Logger)
+		if !readFile {
 			opts.Logger.Warn().Msg("No compose file found. Specify with --file or -f flag")
 			opts.File = defaultFilename
-			readFile = false
 		}
 	}
 
@@ -101,15 +103,14 @@ func addServiceRun(opts *addServiceOpts) error {
 		}
 	}
 
-	p, err := parser.AppendToYAML(b, opts.Command)
+	p, err := parser.AppendToYAML(b, opts.Command, opts.options(outputFile(opts.Write || readFile, opts.File),
+		parser.WithServiceName(opts.ServiceName),
+		parser.WithExtendsAnchor(opts.ExtendsAnchor),
+	)...)
 	if err != nil {
 		return err
 	}
 
-	if opts.ServiceName != "" {
-		p.SetServiceName(opts.ServiceName)
-	}
-
 	err = p.Parse()
 	if err != nil {
 		return err
@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/profclems/compozify/pkg/engine"
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+type captureOpts struct {
+	parserFlags
+
+	Containers  []string
+	Host        string
+	Networks    []string
+	Labels      []string
+	OutFilePath string
+	Target      string
+	Write       bool
+
+	Logger *zerolog.Logger
+}
+
+func newCaptureCmd(logger *zerolog.Logger) *cobra.Command {
+	opts := captureOpts{
+		Logger: logger,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "capture [flags] [CONTAINER...]",
+		Short: "generate a docker compose file from running containers",
+		Long: `Inspects containers with the Docker Engine API and generates a docker compose file with a service for each container.
+The containers are selected by name or ID, or with --network and --label to capture all running containers
+matching the filters. A container must be connected to one of the networks and have all of the labels,
+so --network and --label together select the containers matching both. The user defined networks and named volumes of the containers
+are added to the docker compose file.
+The docker daemon is reached with --host, DOCKER_HOST or the default unix socket.
+`,
+		Example: `
+# capture containers by name
+$ compozify capture web db
+
+# capture all containers connected to the backend network
+$ compozify capture --network backend
+
+# capture all containers with a label and write to file
+$ compozify capture -l com.example.stack=shop -w -o compose.yml
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 0 && len(opts.Networks) == 0 && len(opts.Labels) == 0 {
+				return errors.New("specify the containers to capture, or select them with --network or --label")
+			}
+			opts.Containers = args
+			return captureRun(cmd.Context(), &opts)
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Host, "host", "H", "", "docker daemon address. Defaults to DOCKER_HOST or "+engine.DefaultHost)
+	cmd.Flags().StringArrayVar(&opts.Networks, "network", nil, "capture the running containers connected to the network")
+	cmd.Flags().StringArrayVarP(&opts.Labels, "label", "l", nil, "capture the running containers with the label, KEY or KEY=VALUE")
+	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "write to file")
+	cmd.Flags().StringVarP(&opts.OutFilePath, "out", "o", defaultFilename, "output file path")
+	cmd.Flags().StringVar(&opts.Target, "target", "3.8", "docker compose file format version. Set to empty to omit the version")
+	opts.parserFlags.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+func captureRun(ctx context.Context, opts *captureOpts) error {
+	log := opts.Logger
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	client, err := engine.NewClient(opts.Host)
+	if err != nil {
+		return err
+	}
+
+	filters := engine.Filters{}
+	if len(opts.Networks) > 0 {
+		filters["network"] = opts.Networks
+	}
+	if len(opts.Labels) > 0 {
+		filters["label"] = opts.Labels
+	}
+
+	log.Info().Msg("Inspecting containers")
+	res, err := client.Capture(ctx, opts.Containers, filters)
+	if err != nil {
+		return err
+	}
+	log.Info().Msgf("Captured %d containers, %d networks and %d volumes", len(res.Containers), len(res.Networks), len(res.Volumes))
+
+	p, err := composeFromInspect(res, log, opts.options(outputFile(opts.Write, opts.OutFilePath), parser.WithTarget(opts.Target)))
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msg("Docker compose file generated")
+	return printOutput(p, log, opts.Write, opts.OutFilePath)
+}
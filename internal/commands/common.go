@@ -1,14 +1,132 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	"github.com/rs/zerolog"
+	"github.com/spf13/pflag"
 
 	"github.com/profclems/compozify/pkg/parser"
+	"github.com/profclems/compozify/pkg/render"
 )
 
+// parserFlags are the flags shared by the commands which convert docker run commands.
+type parserFlags struct {
+	Strict   bool
+	Indent   int
+	Annotate bool
+}
+
+func (f *parserFlags) addFlags(fs *pflag.FlagSet) {
+	fs.BoolVar(&f.Strict, "strict", false, "fail on unknown docker run flags and flags not supported in docker compose instead of dropping them")
+	fs.BoolVar(&f.Annotate, "annotate", false, "comment each attribute with the docker flag it was converted from and list the dropped flags")
+	fs.IntVar(&f.Indent, "indent", 0, "number of spaces used to indent the compose file. From 2 to 9, defaults to the indentation of an existing file or 4")
+}
+
+// options returns the parser options for a compose file written to or appended to composeFile.
+// Relative env files are only rebased onto the directory of composeFile when it is not empty,
+// the output printed to stdout keeps them relative to the current directory like docker run.
+func (f *parserFlags) options(composeFile string, opts ...parser.Option) []parser.Option {
+	opts = append(opts,
+		parser.WithStrict(f.Strict),
+		parser.WithAnnotate(f.Annotate),
+	)
+	if composeFile != "" {
+		opts = append(opts, parser.WithEnvFileDir(filepath.Dir(composeFile)))
+	}
+	if f.Indent != 0 {
+		opts = append(opts, parser.WithIndent(f.Indent))
+	}
+	return opts
+}
+
+// commandInput reads the docker command from a file or stdin instead of the arguments.
+type commandInput struct {
+	FromFile string
+}
+
+func (c *commandInput) addFlags(fs *pflag.FlagSet) {
+	fs.StringVar(&c.FromFile, "from-file", "", "read the docker command from a file instead of the arguments. Use - to read from stdin")
+}
+
+// command returns the docker command read from --from-file, from stdin when the only argument is "-",
+// or joined from the arguments.
+func (c *commandInput) command(args []string, stdin io.Reader) (string, error) {
+	file := c.FromFile
+	switch {
+	case file != "" && len(args) > 0:
+		return "", errors.New("--from-file cannot be used with a docker command argument")
+	case file == "" && len(args) == 1 && args[0] == "-":
+		file = "-"
+	case file == "":
+		return strings.Join(args, " "), nil
+	}
+
+	r := stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return "", err
+		}
+		defer f.Close()
+		r = f
+	}
+	return readCommand(r)
+}
+
+// readCommand reads a single docker command which may span multiple lines, joining the lines continued
+// with a trailing backslash. Empty lines and comments are skipped and a leading "$ " prompt is removed.
+// Reading more than one command is an error, as only the first one would be converted.
+func readCommand(r io.Reader) (string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+
+	var commands []string
+	var parts []string
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if len(parts) == 0 {
+			line = strings.TrimPrefix(line, "$ ")
+		}
+		line, continued := strings.CutSuffix(line, `\`)
+		parts = append(parts, strings.TrimSpace(line))
+		if !continued {
+			commands = append(commands, strings.Join(parts, " "))
+			parts = nil
+		}
+	}
+	if len(parts) > 0 {
+		commands = append(commands, strings.Join(parts, " "))
+	}
+
+	switch len(commands) {
+	case 0:
+		return "", errors.New("no docker command to convert")
+	case 1:
+		return commands[0], nil
+	}
+	return "", fmt.Errorf("expected a single docker command, got %d commands: %q", len(commands), commands)
+}
+
+// outputFile returns path when the compose file is written to it and an empty path otherwise.
+func outputFile(write bool, path string) string {
+	if !write {
+		return ""
+	}
+	return path
+}
+
 func printOutput(parser *parser.Parser, log *zerolog.Logger, writeToFile bool, path string) error {
 	writer := os.Stdout
 	var err error
@@ -29,3 +147,68 @@ func printOutput(parser *parser.Parser, log *zerolog.Logger, writeToFile bool, p
 	_, err = fmt.Fprintf(writer, "%s", parser.String())
 	return err
 }
+
+// printFiles prints rendered files to stdout or writes them when writeToFile is set.
+// A single file is written to path, multiple files are written into the path directory.
+// An empty path uses the file names suggested by the renderer.
+func printFiles(files []render.File, log *zerolog.Logger, writeToFile bool, path string) error {
+	if !writeToFile {
+		_, err := os.Stdout.Write(render.Concat(files))
+		return err
+	}
+
+	dir := path
+	if len(files) == 1 {
+		dir = ""
+		if path != "" {
+			files[0].Name = path
+		}
+	}
+
+	for _, file := range files {
+		name := filepath.Join(dir, file.Name)
+		log.Info().Msgf("Writing to file %s", name)
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(name, file.Data, 0o644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendService adds the service of p to the project of compose, renaming it with a numeric
+// suffix when the project has a service with the same name. It returns the parser rendering
+// the project, which is p itself for the first service.
+func appendService(compose, p *parser.Parser) *parser.Parser {
+	if compose == nil {
+		return p
+	}
+	project := compose.Project()
+	s := p.Service()
+	name := s.Name
+	for i := 2; project.Service(name) != nil; i++ {
+		name = s.Name + "-" + strconv.Itoa(i)
+	}
+	s.Name = name
+	project.Services = append(project.Services, s)
+	return compose
+}
+
+// composeFileNames are the docker compose file names looked up in the current directory.
+var composeFileNames = []string{"docker-compose.yml", "docker-compose.yaml", "compose.yml", "compose.yaml"}
+
+// findComposeFile searches the current directory for a docker compose file.
+func findComposeFile(log *zerolog.Logger) (string, bool) {
+	log.Info().Msg("No compose file specified. Searching for compose file in current directory")
+
+	for _, file := range composeFileNames {
+		if _, err := os.Stat(file); err == nil {
+			log.Info().Msgf("Found compose file: %s", file)
+			return file, true
+		}
+	}
+
+	return "", false
+}
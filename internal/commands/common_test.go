@@ -0,0 +1,163 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+func TestReadCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "single line",
+			input: "docker run -p 80:80 nginx\n",
+			want:  "docker run -p 80:80 nginx",
+		},
+		{
+			name:  "continued lines",
+			input: "docker run -d \\\n  -p 80:80 \\\n  nginx\n",
+			want:  "docker run -d -p 80:80 nginx",
+		},
+		{
+			name:  "comments, empty lines and prompt",
+			input: "# start nginx\n\n$ docker run \\\n  -p 80:80 nginx\n",
+			want:  "docker run -p 80:80 nginx",
+		},
+		{
+			name:  "trailing backslash at end of input",
+			input: "docker run nginx \\",
+			want:  "docker run nginx",
+		},
+		{
+			name:    "script with other commands",
+			input:   "#!/bin/sh\nset -e\ndocker run -d \\\n -p 80:80 nginx",
+			wantErr: `expected a single docker command, got 2 commands: ["set -e" "docker run -d -p 80:80 nginx"]`,
+		},
+		{
+			name:    "command after the docker command",
+			input:   "docker run nginx\necho done\n",
+			wantErr: "expected a single docker command, got 2 commands",
+		},
+		{
+			name:    "prompt on each command",
+			input:   "$ docker run nginx\n$ docker run redis\n",
+			wantErr: `got 2 commands: ["docker run nginx" "docker run redis"]`,
+		},
+		{
+			name:    "no command",
+			input:   "# nothing here\n\n",
+			wantErr: "no docker command to convert",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readCommand(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCommandInput(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "command.sh")
+	require.NoError(t, os.WriteFile(file, []byte("docker run \\\n  redis\n"), 0o600))
+
+	tests := []struct {
+		name     string
+		fromFile string
+		args     []string
+		stdin    string
+		want     string
+		wantErr  string
+	}{
+		{
+			name: "arguments",
+			args: []string{"docker", "run", "-p", "80:80", "nginx"},
+			want: "docker run -p 80:80 nginx",
+		},
+		{
+			name:  "stdin argument",
+			args:  []string{"-"},
+			stdin: "docker run \\\n  nginx\n",
+			want:  "docker run nginx",
+		},
+		{
+			name:     "stdin with --from-file",
+			fromFile: "-",
+			stdin:    "docker run nginx",
+			want:     "docker run nginx",
+		},
+		{
+			name:     "file",
+			fromFile: file,
+			want:     "docker run redis",
+		},
+		{
+			name:     "file and arguments",
+			fromFile: file,
+			args:     []string{"docker", "run", "nginx"},
+			wantErr:  "--from-file cannot be used with a docker command argument",
+		},
+		{
+			name:     "stdin with --from-file and stdin argument",
+			fromFile: "-",
+			args:     []string{"-"},
+			wantErr:  "--from-file cannot be used with a docker command argument",
+		},
+		{
+			name:     "missing file",
+			fromFile: filepath.Join(t.TempDir(), "missing.sh"),
+			wantErr:  "no such file or directory",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := commandInput{FromFile: tt.fromFile}
+			got, err := input.command(tt.args, strings.NewReader(tt.stdin))
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParserFlagsEnvFileDir(t *testing.T) {
+	tests := []struct {
+		name        string
+		composeFile string
+		want        string
+	}{
+		{name: "printed to stdout", want: "config/app.env"},
+		{name: "written to a file", composeFile: outputFile(true, "config/compose.yml"), want: "app.env"},
+		{name: "not written", composeFile: outputFile(false, "config/compose.yml"), want: "config/app.env"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var flags parserFlags
+			p, err := parser.New("docker run --env-file config/app.env nginx", flags.options(tt.composeFile)...)
+			require.NoError(t, err)
+			require.NoError(t, p.Parse())
+			require.Equal(t, []string{tt.want}, p.Service().EnvFile)
+		})
+	}
+}
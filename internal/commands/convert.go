@@ -8,16 +8,25 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/profclems/compozify/pkg/parser"
+	"github.com/profclems/compozify/pkg/render"
 )
 
 var defaultFilename = "compose.yml"
 
 type convertOpts struct {
+	parserFlags
+	commandInput
+
 	Command       string
 	OutFilePath   string
 	ServiceName   string
+	Target        string
 	Write         bool
 	AppendService bool
+	ExtendsAnchor string
+	Format        string
+	// OutFileSet reports whether the --out flag was set.
+	OutFileSet bool
 
 	Logger *zerolog.Logger
 }
@@ -28,8 +37,19 @@ func newConvertCmd(logger *zerolog.Logger) *cobra.Command {
 	}
 
 	cmd := &cobra.Command{
-		Use:   "convert [flags] DOCKER_RUN_COMMAND",
+		Use:   "convert [flags] DOCKER_RUN_COMMAND | -",
 		Short: "convert docker run command to docker compose file",
+		Long: `Converts a docker run or docker service create command to a docker compose file.
+The command is read from stdin with "-" or from a file with --from-file,
+where it may span multiple lines continued with a trailing backslash.
+The input must hold a single command, use extract to convert the docker commands of a script.
+
+Environment variables passed through from the host like -e FOO are only kept by the compose and
+systemd formats, the devcontainer format reads them with ${localEnv:FOO} and the other formats
+leave them out. The kubernetes format puts the environment variables whose names contain PASSWORD,
+PASSWD, SECRET, TOKEN, API_KEY, PRIVATE_KEY or CREDENTIAL into a Secret and the other ones into
+a ConfigMap.
+`,
 		Example: `
 # convert and write to stdout
 $ compozify convert "docker run -i -t --rm alpine"
@@ -40,33 +60,63 @@ $ compozify convert -w "docker run -i -t --rm alpine"
 # write to file with custom name
 $ compozify convert -w -o docker-compose.yml "docker run -i -t --rm alpine"
 
+# convert to kubernetes manifests
+$ compozify convert --format kubernetes "docker run -p 8080:80 nginx"
+
+# convert a swarm service for docker stack deploy
+$ compozify convert "docker service create --replicas 3 --constraint node.role==worker -p 8080:80 nginx"
+
 # alternative usage specifying beginning of docker run command
 $ compozify convert -w -- docker run -i -t --rm alpine
+
+# read a long docker run command from stdin
+$ pbpaste | compozify convert -
+
+# read a docker run command from a file
+$ compozify convert --from-file run.sh
 `,
 		RunE: func(cmd *cobra.Command, args []string) error {
 
-			switch len(args) {
-			case 0:
+			if len(args) == 0 && opts.FromFile == "" {
 				return cmd.Help()
-			case 1:
-				opts.Command = args[0]
-			default:
-				opts.Command = strings.Join(args, " ")
 			}
+			command, err := opts.command(args, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			opts.Command = command
 
 			if opts.AppendService && opts.OutFilePath == "" {
 				return fmt.Errorf("--append-service requires --out flag")
 			}
 
+			if opts.ExtendsAnchor != "" && !opts.AppendService {
+				return fmt.Errorf("--extends-anchor requires --append-service flag")
+			}
+
+			if opts.AppendService && opts.Format != render.DefaultFormat {
+				return fmt.Errorf("--append-service can only be used with the %s format", render.DefaultFormat)
+			}
+
+			if opts.Annotate && opts.Format != render.DefaultFormat {
+				return fmt.Errorf("--annotate can only be used with the %s format", render.DefaultFormat)
+			}
+
+			opts.OutFileSet = cmd.Flags().Changed("out")
+
 			return convertRun(&opts)
 		},
-		Args: cobra.MinimumNArgs(1),
 	}
 
 	cmd.Flags().BoolVarP(&opts.AppendService, "append-service", "a", false, "append service to existing compose file. Requires --out flag")
 	cmd.Flags().StringVarP(&opts.ServiceName, "service-name", "n", "", "Name of the service")
 	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "write to file")
-	cmd.Flags().StringVarP(&opts.OutFilePath, "out", "o", defaultFilename, "output file path")
+	cmd.Flags().StringVarP(&opts.OutFilePath, "out", "o", defaultFilename, "output file path. For formats rendering multiple files, the directory to write them to")
+	cmd.Flags().StringVar(&opts.ExtendsAnchor, "extends-anchor", "", "anchor of the existing compose file the service extends with a merge key. Requires --append-service flag")
+	cmd.Flags().StringVar(&opts.Target, "target", "3.8", "docker compose file format version. Set to empty to omit the version")
+	cmd.Flags().StringVar(&opts.Format, "format", render.DefaultFormat, fmt.Sprintf("output format. One of %s", strings.Join(render.Formats(), ", ")))
+	opts.commandInput.addFlags(cmd.Flags())
+	opts.parserFlags.addFlags(cmd.Flags())
 
 	return cmd
 }
@@ -80,28 +130,43 @@ func convertRun(opts *convertOpts) (err error) {
 	if opts.AppendService {
 		log.Info().Msg("Appending service to existing compose file")
 		return addServiceRun(&addServiceOpts{
-			Logger:  log,
-			File:    opts.OutFilePath,
-			Command: opts.Command,
-			Write:   opts.Write,
+			parserFlags:   opts.parserFlags,
+			Logger:        log,
+			File:          opts.OutFilePath,
+			Command:       opts.Command,
+			Write:         opts.Write,
+			ServiceName:   opts.ServiceName,
+			ExtendsAnchor: opts.ExtendsAnchor,
 		})
 	}
 
-	p, err := parser.New(opts.Command)
+	p, err := parser.New(opts.Command, opts.options(outputFile(opts.Write && opts.Format == render.DefaultFormat, opts.OutFilePath),
+		parser.WithServiceName(opts.ServiceName),
+		parser.WithTarget(opts.Target),
+	)...)
 	if err != nil {
 		return err
 	}
 
-	if opts.ServiceName != "" {
-		p.SetServiceName(opts.ServiceName)
-	}
-
 	log.Info().Msg("Generating Docker compose file")
 	err = p.Parse()
 	if err != nil {
 		return err
 	}
-	log.Info().Msg("Docker compose file generated")
+	if opts.Format == render.DefaultFormat {
+		log.Info().Msg("Docker compose file generated")
+		return printOutput(p, log, opts.Write, opts.OutFilePath)
+	}
 
-	return printOutput(p, log, opts.Write, opts.OutFilePath)
+	log.Info().Msgf("Rendering %s output", opts.Format)
+	files, err := render.Render(opts.Format, p)
+	if err != nil {
+		return err
+	}
+
+	out := ""
+	if opts.OutFileSet {
+		out = opts.OutFilePath
+	}
+	return printFiles(files, log, opts.Write, out)
 }
@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"io"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConvertFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "annotate with another format",
+			args:    []string{"--annotate", "--format", "kubernetes", "docker run nginx"},
+			wantErr: "--annotate can only be used with the compose format",
+		},
+		{
+			name:    "append service with another format",
+			args:    []string{"--append-service", "-o", "compose.yml", "--format", "kubernetes", "docker run nginx"},
+			wantErr: "--append-service can only be used with the compose format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger := zerolog.Nop()
+			cmd := newConvertCmd(&logger)
+			cmd.SetArgs(tt.args)
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			require.EqualError(t, cmd.Execute(), tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,194 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/profclems/compozify/pkg/extract"
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+type extractOpts struct {
+	parserFlags
+
+	Files       []string
+	List        bool
+	OutFilePath string
+	Target      string
+	Write       bool
+
+	Logger *zerolog.Logger
+}
+
+func newExtractCmd(logger *zerolog.Logger) *cobra.Command {
+	opts := extractOpts{
+		Logger: logger,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "extract [flags] FILE...",
+		Short: "extract docker run commands from documentation and scripts into a docker compose file",
+		Long: `Searches files for docker run commands and converts each into a service of a single docker compose file.
+The docker network create and docker volume create commands found are converted into top-level networks
+and volumes of the same file, and services run with --network on a created network are connected to it.
+Services running an image built with docker build before the docker run command get a build section with the
+context and options of the build. The build context is resolved against the directory of the file it is found in
+and written relative to the docker compose file.
+Markdown files are searched in their fenced code blocks, shell scripts, Dockerfiles and other files
+in all lines including comments. Shell prompts like "$ " and backslash line continuations are handled.
+Each service is preceded by a comment with the file and line of its command.
+Commands which fail to convert are reported and skipped, unless --strict is set.
+`,
+		Example: `
+# convert the docker run commands of a README
+$ compozify extract README.md
+
+# list the docker run commands found in files
+$ compozify extract --list README.md install.sh Dockerfile
+
+# write to file
+$ compozify extract -w -o compose.yml docs/*.md
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Files = args
+			return extractRun(&opts)
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	cmd.Flags().BoolVar(&opts.List, "list", false, "list the docker commands found instead of converting them")
+	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "write to file")
+	cmd.Flags().StringVarP(&opts.OutFilePath, "out", "o", defaultFilename, "output file path")
+	cmd.Flags().StringVar(&opts.Target, "target", "3.8", "docker compose file format version. Set to empty to omit the version")
+	opts.parserFlags.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+func extractRun(opts *extractOpts) error {
+	log := opts.Logger
+
+	var commands []extract.Command
+	for _, file := range opts.Files {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		found, err := extract.Extract(file, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", file, err)
+		}
+		log.Info().Msgf("Found %d docker commands in %s", len(found), file)
+		commands = append(commands, found...)
+	}
+
+	if opts.List {
+		for _, c := range commands {
+			fmt.Fprintf(os.Stdout, "%s:%d: %s\n", c.File, c.Line, c.Command)
+		}
+		return nil
+	}
+
+	var compose *parser.Parser
+	var networks []*parser.NetworkConfig
+	var volumes []*parser.VolumeConfig
+	var builds []*parser.BuildConfig
+	seen := map[string]string{}
+	for _, c := range commands {
+		source := fmt.Sprintf("%s:%d", c.File, c.Line)
+		if first, ok := seen[c.Command]; ok {
+			log.Info().Msgf("%s: skipping docker run command already found at %s", source, first)
+			continue
+		}
+		seen[c.Command] = source
+		log.Info().Msgf("%s: %s", source, c.Command)
+
+		var err error
+		switch {
+		case strings.HasPrefix(c.Command, extract.DockerBuild):
+			var build *parser.BuildConfig
+			if build, err = parser.NewBuild(c.Command); err == nil && len(build.Tags) == 0 {
+				err = errors.New("missing image tag")
+			}
+			if err == nil {
+				// the context is relative to the directory of the script rather than the working directory
+				err = build.Rebase(filepath.Dir(c.File), filepath.Dir(opts.OutFilePath))
+			}
+			if err == nil {
+				builds = append(builds, build)
+			}
+		case strings.HasPrefix(c.Command, extract.DockerNetworkCreate):
+			var network *parser.NetworkConfig
+			if network, err = parser.NewNetwork(c.Command); err == nil {
+				networks = append(networks, network)
+			}
+		case strings.HasPrefix(c.Command, extract.DockerVolumeCreate):
+			var volume *parser.VolumeConfig
+			if volume, err = parser.NewVolume(c.Command); err == nil {
+				volumes = append(volumes, volume)
+			}
+		default:
+			var p *parser.Parser
+			p, err = parser.New(c.Command, opts.options(outputFile(opts.Write, opts.OutFilePath), parser.WithTarget(opts.Target))...)
+			if err == nil {
+				err = p.Parse()
+			}
+			if err == nil && p.Service().Image == "" {
+				err = errors.New("missing image")
+			}
+			if err == nil {
+				p.Service().Comment = source
+				if build := lastBuild(builds, p.Service().Image); build != nil {
+					p.Service().SetBuild(build)
+				}
+				compose = appendService(compose, p)
+			}
+		}
+		if err != nil {
+			if opts.Strict {
+				return fmt.Errorf("%s: %w", source, err)
+			}
+			log.Warn().Msgf("%s: skipping command: %s", source, err)
+		}
+	}
+
+	if compose == nil {
+		return errors.New("no docker run commands found")
+	}
+	project := compose.Project()
+	for _, network := range networks {
+		if project.Network(network.Name) == nil {
+			project.Networks = append(project.Networks, network)
+		}
+	}
+	for _, volume := range volumes {
+		if project.Volume(volume.Name) == nil {
+			project.Volumes = append(project.Volumes, volume)
+		}
+	}
+	project.ConnectNetworks()
+	if _, err := compose.Render(); err != nil {
+		return err
+	}
+
+	log.Info().Msg("Docker compose file generated")
+	return printOutput(compose, log, opts.Write, opts.OutFilePath)
+}
+
+// lastBuild returns the last of the builds tagging the image, which is the one docker run uses
+// as the builds are the ones found before the docker run command.
+func lastBuild(builds []*parser.BuildConfig, image string) *parser.BuildConfig {
+	for i := len(builds) - 1; i >= 0; i-- {
+		if builds[i].Tagged(image) {
+			return builds[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractBuilds(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "docs", "setup.sh")
+	require.NoError(t, os.MkdirAll(filepath.Dir(script), 0o755))
+	require.NoError(t, os.WriteFile(script, []byte(`#!/bin/sh
+docker build -t api:latest --target dev ..
+docker run -d api
+docker build -t api --target prod -f ../docker/Dockerfile ..
+docker run -d -p 80:80 api
+docker run -d web
+docker build -t web .
+`), 0o644))
+
+	logger := zerolog.Nop()
+	out := filepath.Join(dir, "deploy", "compose.yml")
+	require.NoError(t, os.MkdirAll(filepath.Dir(out), 0o755))
+	opts := extractOpts{
+		Files:       []string{script},
+		OutFilePath: out,
+		Write:       true,
+		Logger:      &logger,
+	}
+	require.NoError(t, extractRun(&opts))
+
+	b, err := os.ReadFile(out)
+	require.NoError(t, err)
+	require.Equal(t, `services:
+    # `+script+`:3
+    api:
+        build:
+            context: ..
+            target: dev
+        image: api
+    # `+script+`:5
+    api-2:
+        ports:
+            - 80:80
+        build:
+            context: ..
+            dockerfile: docker/Dockerfile
+            target: prod
+        image: api
+    # `+script+`:6
+    web:
+        image: web
+`, string(b))
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+type flagsOpts struct {
+	Names         []string
+	ServiceCreate bool
+	Status        string
+	JSON          bool
+}
+
+func newFlagsCmd() *cobra.Command {
+	opts := flagsOpts{}
+
+	cmd := &cobra.Command{
+		Use:   "flags [flags] [FLAG...]",
+		Short: "list the docker run flags and how they are converted",
+		Long: `Lists the docker run flags known to compozify with their aliases, type, the docker compose attribute
+they are converted into and their status:
+  supported  the flag is converted into a docker compose attribute
+  dropped    the flag has no docker compose equivalent and is dropped
+  todo       the flag is dropped but could be converted in the future
+Unknown flags are dropped too. Pass FLAG names or aliases to only list these flags.
+`,
+		Example: `
+# list all docker run flags
+$ compozify flags
+
+# check how -p and --gpus are converted
+$ compozify flags p gpus
+
+# list the docker service create flags which are dropped, as JSON
+$ compozify flags --service-create --status dropped --json
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			opts.Names = args
+			return flagsRun(&opts, os.Stdout)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.ServiceCreate, "service-create", false, "list the docker service create flags instead of the docker run flags")
+	cmd.Flags().StringVar(&opts.Status, "status", "", "only list the flags with the status. One of supported, dropped, todo")
+	cmd.Flags().BoolVar(&opts.JSON, "json", false, "print the flags as JSON")
+
+	return cmd
+}
+
+func flagsRun(opts *flagsOpts, w io.Writer) error {
+	switch parser.FlagStatus(opts.Status) {
+	case "", parser.FlagSupported, parser.FlagDropped, parser.FlagTodo:
+	default:
+		return fmt.Errorf("invalid status %q", opts.Status)
+	}
+
+	known := parser.KnownFlags()
+	if opts.ServiceCreate {
+		known = parser.KnownServiceCreateFlags()
+	}
+
+	flags := []parser.FlagInfo{}
+	for _, f := range known {
+		if opts.Status != "" && string(f.Status) != opts.Status {
+			continue
+		}
+		if len(opts.Names) > 0 && !matchFlag(f, opts.Names) {
+			continue
+		}
+		flags = append(flags, f)
+	}
+	if len(opts.Names) > 0 && len(flags) == 0 {
+		return fmt.Errorf("unknown flags %s", strings.Join(opts.Names, ", "))
+	}
+
+	if opts.JSON {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(flags)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "FLAG\tTYPE\tCOMPOSE\tSTATUS")
+	for _, f := range flags {
+		names := make([]string, 0, len(f.Aliases)+1)
+		for _, alias := range f.Aliases {
+			names = append(names, parser.FlagArg(alias))
+		}
+		names = append(names, parser.FlagArg(f.Name))
+		composePath := f.ComposePath
+		if composePath == "" {
+			composePath = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", strings.Join(names, ", "), f.Type, composePath, f.Status)
+	}
+	return tw.Flush()
+}
+
+// matchFlag reports whether the flag or one of its aliases is named, with or without leading dashes.
+func matchFlag(f parser.FlagInfo, names []string) bool {
+	for _, name := range names {
+		name = strings.TrimLeft(name, "-")
+		if name == f.Name {
+			return true
+		}
+		for _, alias := range f.Aliases {
+			if name == alias {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/profclems/compozify/pkg/history"
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+const historyTimeFormat = "2006-01-02 15:04"
+
+type fromHistoryOpts struct {
+	parserFlags
+
+	File        string
+	Shell       string
+	Select      string
+	List        bool
+	Limit       int
+	OutFilePath string
+	Target      string
+	Write       bool
+
+	Logger *zerolog.Logger
+}
+
+func newFromHistoryCmd(logger *zerolog.Logger) *cobra.Command {
+	opts := fromHistoryOpts{
+		Logger: logger,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "from-history [flags] [HISTORY_FILE]",
+		Short: "convert docker run commands from the shell history",
+		Long: `Lists the unique docker run commands of a bash, zsh or fish history file, most recent first,
+and converts the selected ones into a docker compose file.
+The commands are selected by their number with --select, or interactively when it is not set.
+Without HISTORY_FILE, $HISTFILE or the history file of the shell in $SHELL is read.
+The shell writing the history file is detected from its name unless --shell is set.
+`,
+		Example: `
+# choose from the docker run commands of the shell history
+$ compozify from-history
+
+# list the docker run commands of a zsh history
+$ compozify from-history --list ~/.zsh_history
+
+# convert the most recent and the third most recent command
+$ compozify from-history --select 1,3 -w
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.File = args[0]
+			}
+			return fromHistoryRun(&opts, cmd.InOrStdin(), cmd.ErrOrStderr())
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	shells := make([]string, len(history.Shells))
+	for i, shell := range history.Shells {
+		shells[i] = string(shell)
+	}
+	cmd.Flags().StringVar(&opts.Shell, "shell", "", fmt.Sprintf("shell which wrote the history file. One of %s", strings.Join(shells, ", ")))
+	cmd.Flags().StringVar(&opts.Select, "select", "", "numbers of the listed commands to convert, eg: 1,3-4")
+	cmd.Flags().BoolVar(&opts.List, "list", false, "list the docker run commands instead of converting them")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 20, "number of most recent commands listed. Set to 0 to list all")
+	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "write to file")
+	cmd.Flags().StringVarP(&opts.OutFilePath, "out", "o", defaultFilename, "output file path")
+	cmd.Flags().StringVar(&opts.Target, "target", "3.8", "docker compose file format version. Set to empty to omit the version")
+	opts.parserFlags.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+func fromHistoryRun(opts *fromHistoryOpts, in io.Reader, prompt io.Writer) error {
+	log := opts.Logger
+
+	file, shell, err := historyFile(opts.File, history.Shell(opts.Shell))
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msgf("Reading %s history %s", shell, file)
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	entries, err := history.Read(f, shell)
+	f.Close()
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no docker run commands found in %s", file)
+	}
+	if opts.Limit > 0 && len(entries) > opts.Limit {
+		entries = entries[:opts.Limit]
+	}
+
+	if opts.List {
+		printHistory(os.Stdout, entries)
+		return nil
+	}
+
+	selection := opts.Select
+	if selection == "" {
+		printHistory(prompt, entries)
+		fmt.Fprint(prompt, "Select the commands to convert, eg: 1,3-4: ")
+		line, err := bufio.NewReader(in).ReadString('\n')
+		if err != nil && (err != io.EOF || line == "") {
+			return errors.New("no commands selected")
+		}
+		selection = line
+	}
+	indexes, err := parseSelection(selection, len(entries))
+	if err != nil {
+		return err
+	}
+
+	var compose *parser.Parser
+	for _, i := range indexes {
+		entry := entries[i]
+		log.Info().Msgf("Converting %s", entry.Command)
+		p, err := parser.New(entry.Command, opts.options(outputFile(opts.Write, opts.OutFilePath), parser.WithTarget(opts.Target))...)
+		if err != nil {
+			return fmt.Errorf("command %d: %w", i+1, err)
+		}
+		if err := p.Parse(); err != nil {
+			return fmt.Errorf("command %d: %w", i+1, err)
+		}
+		if !entry.Time.IsZero() {
+			p.Service().Comment = "run at " + entry.Time.Format(historyTimeFormat)
+		}
+		compose = appendService(compose, p)
+	}
+	if _, err := compose.Render(); err != nil {
+		return err
+	}
+
+	log.Info().Msg("Docker compose file generated")
+	return printOutput(compose, log, opts.Write, opts.OutFilePath)
+}
+
+// historyFile returns the history file to read and the shell which wrote it.
+func historyFile(file string, shell history.Shell) (string, history.Shell, error) {
+	if shell != "" {
+		if !containsShell(history.Shells, shell) {
+			return "", "", fmt.Errorf("unsupported shell %q", shell)
+		}
+	}
+	if file == "" {
+		file = os.Getenv("HISTFILE")
+	}
+	if file != "" {
+		if shell == "" {
+			shell = history.DetectShell(file)
+		}
+		return file, shell, nil
+	}
+
+	if shell == "" {
+		shell = history.DetectShell(filepath.Base(os.Getenv("SHELL")))
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", "", err
+	}
+	switch shell {
+	case history.Zsh:
+		return filepath.Join(home, ".zsh_history"), shell, nil
+	case history.Fish:
+		data := os.Getenv("XDG_DATA_HOME")
+		if data == "" {
+			data = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(data, "fish", "fish_history"), shell, nil
+	}
+	return filepath.Join(home, ".bash_history"), shell, nil
+}
+
+func containsShell(shells []history.Shell, shell history.Shell) bool {
+	for _, s := range shells {
+		if s == shell {
+			return true
+		}
+	}
+	return false
+}
+
+func printHistory(w io.Writer, entries []history.Entry) {
+	for i, entry := range entries {
+		when := strings.Repeat(" ", len(historyTimeFormat))
+		if !entry.Time.IsZero() {
+			when = entry.Time.Format(historyTimeFormat)
+		}
+		fmt.Fprintf(w, "%3d  %s  %s\n", i+1, when, entry.Command)
+	}
+}
+
+// parseSelection parses a comma separated list of numbers and ranges, eg: 1,3-4,
+// into the indexes of n listed entries.
+func parseSelection(s string, n int) ([]int, error) {
+	var indexes []int
+	seen := map[int]bool{}
+	for _, part := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\r' }) {
+		from, to, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selection %q", part)
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(to); err != nil || last < first {
+				return nil, fmt.Errorf("invalid selection %q", part)
+			}
+		}
+		if first < 1 || last > n {
+			return nil, fmt.Errorf("selection %q is out of range 1-%d", part, n)
+		}
+		for i := first; i <= last; i++ {
+			if !seen[i] {
+				seen[i] = true
+				indexes = append(indexes, i-1)
+			}
+		}
+	}
+	if len(indexes) == 0 {
+		return nil, errors.New("no commands selected")
+	}
+	return indexes, nil
+}
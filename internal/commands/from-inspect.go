@@ -0,0 +1,106 @@
+package commands
+
+import (
+	"io"
+	"os"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/profclems/compozify/pkg/inspect"
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+type fromInspectOpts struct {
+	parserFlags
+
+	File        string
+	OutFilePath string
+	Target      string
+	Write       bool
+
+	Logger *zerolog.Logger
+}
+
+func newFromInspectCmd(logger *zerolog.Logger) *cobra.Command {
+	opts := fromInspectOpts{
+		Logger: logger,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "from-inspect [flags] [FILE]",
+		Short: "generate a docker compose file from docker inspect output",
+		Long: `Generates a docker compose file with a service for each container in the docker inspect output.
+The output is read from FILE, or from stdin when FILE is omitted or "-".
+Environment variables, labels, the command and other settings equal to the defaults of the image
+are left out when the image is part of the docker inspect output.
+`,
+		Example: `
+# generate from the output of docker inspect
+$ docker inspect web db | compozify from-inspect
+
+# leave out the defaults of the image
+$ docker inspect web nginx:1.25 > inspect.json
+$ compozify from-inspect inspect.json
+
+# write to file
+$ docker inspect web | compozify from-inspect -w -o compose.yml
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) > 0 {
+				opts.File = args[0]
+			}
+			return fromInspectRun(&opts)
+		},
+		Args: cobra.MaximumNArgs(1),
+	}
+
+	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "write to file")
+	cmd.Flags().StringVarP(&opts.OutFilePath, "out", "o", defaultFilename, "output file path")
+	cmd.Flags().StringVar(&opts.Target, "target", "3.8", "docker compose file format version. Set to empty to omit the version")
+	opts.parserFlags.addFlags(cmd.Flags())
+
+	return cmd
+}
+
+func fromInspectRun(opts *fromInspectOpts) error {
+	log := opts.Logger
+
+	var r io.Reader = os.Stdin
+	if opts.File != "" && opts.File != "-" {
+		f, err := os.Open(opts.File)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	} else {
+		log.Info().Msg("Reading docker inspect output from stdin")
+	}
+
+	res, err := inspect.Decode(r)
+	if err != nil {
+		return err
+	}
+
+	p, err := composeFromInspect(res, log, opts.options(outputFile(opts.Write, opts.OutFilePath), parser.WithTarget(opts.Target)))
+	if err != nil {
+		return err
+	}
+
+	log.Info().Msg("Docker compose file generated")
+	return printOutput(p, log, opts.Write, opts.OutFilePath)
+}
+
+// composeFromInspect converts the containers of the docker inspect output into a docker compose file.
+// It warns about the containers whose image is missing, as the image defaults cannot be left out.
+// With docker inspect, the images are included by passing them along with the containers.
+func composeFromInspect(res *inspect.Result, log *zerolog.Logger, opts []parser.Option) (*parser.Parser, error) {
+	for _, c := range res.Containers {
+		if res.Image(c.Image) == nil {
+			log.Warn().Msgf("Image %s of container %s not found. Settings equal to the image defaults are kept", c.ImageName(), c.ContainerName())
+		}
+		log.Debug().Msgf("Docker run arguments of container %s: %q", c.ContainerName(), inspect.RunArgs(c, res.Image(c.Image)))
+	}
+	return inspect.Compose(res, opts...)
+}
@@ -26,6 +26,12 @@ func NewRootCmd(logger *zerolog.Logger, version version.Info) *cobra.Command {
 
 	cmd.AddCommand(newConvertCmd(logger))
 	cmd.AddCommand(newAddServiceCmd(logger))
+	cmd.AddCommand(newUpdateServiceCmd(logger))
+	cmd.AddCommand(newFromInspectCmd(logger))
+	cmd.AddCommand(newCaptureCmd(logger))
+	cmd.AddCommand(newExtractCmd(logger))
+	cmd.AddCommand(newFromHistoryCmd(logger))
+	cmd.AddCommand(newFlagsCmd())
 
 	return cmd
 }
@@ -0,0 +1,97 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog"
+	"github.com/spf13/cobra"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+type updateServiceOpts struct {
+	parserFlags
+
+	Logger *zerolog.Logger
+
+	File        string
+	Command     string
+	Write       bool
+	ServiceName string
+}
+
+func newUpdateServiceCmd(logger *zerolog.Logger) *cobra.Command {
+	opts := updateServiceOpts{
+		Logger: logger,
+	}
+	cmd := &cobra.Command{
+		Use:   "update-service [flags] DOCKER_RUN_COMMAND",
+		Short: "Merge docker run flags into an existing service of a docker-compose file",
+		Long: `Converts the docker run flags to docker compose and merges them into an existing service of a docker-compose file.
+Lists like ports and volumes are extended with the values not yet present, maps like environment and labels are
+merged by key and single values like image and restart are overwritten. Comments of the existing service are preserved.
+If no file is specified, compozify will look for a docker compose file in the current directory.
+Expected file names are docker-compose.[yml,yaml], compose.[yml,yaml]
+`,
+		Example: `
+# add an environment variable and a port mapping to the api service
+$ compozify update-service -n api "docker run -e FOO=1 -p 9000:9000"
+
+# update the image of the api service and write to file
+$ compozify update-service -w -f /path/to/docker-compose.yml -n api "docker run api:2.0"
+
+# alternative usage specifying beginning of docker run command without quotes
+$ compozify update-service -w -n api -- docker run -e FOO=1 -p 9000:9000
+`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			switch len(args) {
+			case 0:
+				return cmd.Help()
+			case 1:
+				opts.Command = args[0]
+			default:
+				opts.Command = strings.Join(args, " ")
+			}
+
+			return updateServiceRun(&opts)
+		},
+		Args: cobra.MinimumNArgs(1),
+	}
+
+	cmd.Flags().StringVarP(&opts.ServiceName, "service-name", "n", "", "Name of the service to update")
+	cmd.Flags().BoolVarP(&opts.Write, "write", "w", false, "write to file")
+	cmd.Flags().StringVarP(&opts.File, "file", "f", "", "Compose file path")
+	opts.parserFlags.addFlags(cmd.Flags())
+	_ = cmd.MarkFlagRequired("service-name")
+
+	return cmd
+}
+
+func updateServiceRun(opts *updateServiceOpts) error {
+	if opts.File == "" {
+		var found bool
+		opts.File, found = findComposeFile(opts.Logger)
+		if !found {
+			return errors.New("no compose file found. Specify with --file or -f flag")
+		}
+	}
+
+	b, err := os.ReadFile(opts.File)
+	if err != nil {
+		return err
+	}
+
+	p, err := parser.UpdateYAML(b, opts.ServiceName, opts.Command, opts.options(opts.File)...)
+	if err != nil {
+		return err
+	}
+
+	err = p.Parse()
+	if err != nil {
+		return err
+	}
+
+	return printOutput(p, opts.Logger, opts.Write, opts.File)
+}
@@ -0,0 +1,227 @@
+// Package engine is a minimal client of the Docker Engine API reading the containers,
+// images, networks and volumes needed to capture running containers into a docker compose file.
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/inspect"
+)
+
+// DefaultHost is the address of the docker daemon used when DOCKER_HOST is not set.
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// Client reads objects from the Docker Engine API.
+type Client struct {
+	http *http.Client
+	base string
+}
+
+// NewClient returns a client for the docker daemon listening on host, a unix:// socket or a
+// tcp:// or http:// address. An empty host uses DOCKER_HOST, or DefaultHost when it is not set.
+// TLS connections are not supported.
+func NewClient(host string) (*Client, error) {
+	if host == "" {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if host == "" {
+		host = DefaultHost
+	}
+
+	scheme, addr, ok := strings.Cut(host, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid docker host %q", host)
+	}
+	switch scheme {
+	case "unix":
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", addr)
+			},
+		}
+		return &Client{http: &http.Client{Transport: transport}, base: "http://docker"}, nil
+	case "tcp", "http":
+		return &Client{http: &http.Client{}, base: "http://" + strings.TrimSuffix(addr, "/")}, nil
+	}
+	return nil, fmt.Errorf("unsupported docker host %q: only unix, tcp and http hosts are supported", host)
+}
+
+// Filters select containers by label, network, name or any other filter of the Engine API, eg: label=env=prod.
+type Filters map[string][]string
+
+// ContainerIDs returns the IDs of the running containers matching the filters.
+func (c *Client) ContainerIDs(ctx context.Context, filters Filters) ([]string, error) {
+	query := url.Values{}
+	if len(filters) > 0 {
+		b, err := json.Marshal(filters)
+		if err != nil {
+			return nil, err
+		}
+		query.Set("filters", string(b))
+	}
+
+	var containers []struct {
+		ID string `json:"Id"`
+	}
+	if err := c.get(ctx, "/containers/json", query, &containers); err != nil {
+		return nil, err
+	}
+	ids := make([]string, len(containers))
+	for i, container := range containers {
+		ids[i] = container.ID
+	}
+	return ids, nil
+}
+
+// Container returns the container with the given name or ID.
+func (c *Client) Container(ctx context.Context, id string) (*inspect.Container, error) {
+	var container inspect.Container
+	if err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/json", nil, &container); err != nil {
+		return nil, err
+	}
+	return &container, nil
+}
+
+// Image returns the image with the given name or ID.
+func (c *Client) Image(ctx context.Context, id string) (*inspect.Image, error) {
+	var image inspect.Image
+	if err := c.get(ctx, "/images/"+url.PathEscape(id)+"/json", nil, &image); err != nil {
+		return nil, err
+	}
+	return &image, nil
+}
+
+// Network returns the network with the given name or ID.
+func (c *Client) Network(ctx context.Context, id string) (*inspect.Network, error) {
+	var network inspect.Network
+	if err := c.get(ctx, "/networks/"+url.PathEscape(id), nil, &network); err != nil {
+		return nil, err
+	}
+	return &network, nil
+}
+
+// Volume returns the volume with the given name.
+func (c *Client) Volume(ctx context.Context, name string) (*inspect.Volume, error) {
+	var volume inspect.Volume
+	if err := c.get(ctx, "/volumes/"+url.PathEscape(name), nil, &volume); err != nil {
+		return nil, err
+	}
+	return &volume, nil
+}
+
+// Capture inspects the containers with the given names or IDs and the running containers
+// matching the filters, together with their images, user defined networks and named volumes.
+// Images which were removed since the container was created are left out.
+func (c *Client) Capture(ctx context.Context, ids []string, filters Filters) (*inspect.Result, error) {
+	if len(filters) > 0 {
+		matching, err := c.ContainerIDs(ctx, filters)
+		if err != nil {
+			return nil, err
+		}
+		if len(matching) == 0 {
+			return nil, errors.New("no running containers match the filters")
+		}
+		ids = append(ids[:len(ids):len(ids)], matching...)
+	}
+
+	res := &inspect.Result{}
+	seen := map[string]bool{}
+	for _, id := range ids {
+		container, err := c.Container(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if seen[container.ID] {
+			continue
+		}
+		seen[container.ID] = true
+		res.Containers = append(res.Containers, container)
+
+		if res.Image(container.Image) == nil {
+			image, err := c.Image(ctx, container.Image)
+			if err != nil && !IsNotFound(err) {
+				return nil, err
+			}
+			if image != nil {
+				res.Images = append(res.Images, image)
+			}
+		}
+		for name := range inspect.ServiceNetworks(container) {
+			if res.Network(name) != nil {
+				continue
+			}
+			network, err := c.Network(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			res.Networks = append(res.Networks, network)
+		}
+		for _, name := range inspect.NamedVolumes(container) {
+			if res.Volume(name) != nil {
+				continue
+			}
+			volume, err := c.Volume(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			res.Volumes = append(res.Volumes, volume)
+		}
+	}
+	return res, nil
+}
+
+// Error is an error response of the Engine API.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("docker engine API: %s", e.Message)
+}
+
+// IsNotFound reports whether err is a not found error of the Engine API.
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, v interface{}) error {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the docker daemon: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		var body struct {
+			Message string `json:"message"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Message != "" {
+			apiErr.Message = body.Message
+		} else {
+			apiErr.Message = resp.Status
+		}
+		return apiErr
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,167 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/compozify/pkg/inspect"
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// fakeEngine serves the Engine API from the objects in testdata over a unix socket
+// and returns the docker host to connect to it. Errors reading testdata are returned
+// as internal server errors, failing the client call checked by the test.
+func fakeEngine(t *testing.T) string {
+	t.Helper()
+
+	// unix socket paths are limited to about 100 characters, which t.TempDir can exceed
+	dir, err := os.MkdirTemp("", "engine")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	socket := filepath.Join(dir, "docker.sock")
+	l, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filters") != `{"network":["backend"]}` {
+			w.Write([]byte(`[]`))
+			return
+		}
+		w.Write([]byte(`[{"Id": "5e1f0c2d9a8b"}, {"Id": "9c4d3e2f1a0b"}]`))
+	})
+	for _, kind := range []string{"containers", "images", "networks", "volumes"} {
+		kind := kind
+		mux.HandleFunc("/"+kind+"/", func(w http.ResponseWriter, r *http.Request) {
+			ref := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/"+kind+"/"), "/json")
+			b, err := findObject(kind, ref)
+			if err != nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				w.Write([]byte(`{"message": ` + strconv.Quote(err.Error()) + `}`))
+				return
+			}
+			if b != nil {
+				w.Write(b)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "No such object: ` + ref + `"}`))
+		})
+	}
+
+	server := &http.Server{Handler: mux}
+	go server.Serve(l)
+	t.Cleanup(func() { server.Close() })
+
+	return "unix://" + socket
+}
+
+// findObject returns the object in testdata/<kind> with the given name or ID prefix.
+// It returns nil when there is no such object.
+func findObject(kind, ref string) ([]byte, error) {
+	files, err := filepath.Glob(filepath.Join("testdata", kind, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		var object struct {
+			ID   string `json:"Id"`
+			Name string `json:"Name"`
+		}
+		if err := json.Unmarshal(b, &object); err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		if strings.TrimPrefix(object.Name, "/") == ref || strings.HasPrefix(object.ID, ref) {
+			return b, nil
+		}
+	}
+	return nil, nil
+}
+
+func TestCapture(t *testing.T) {
+	client, err := NewClient(fakeEngine(t))
+	require.NoError(t, err)
+
+	res, err := client.Capture(context.Background(), []string{"web"}, Filters{"network": {"backend"}})
+	require.NoError(t, err)
+	require.Len(t, res.Containers, 2)
+	require.Len(t, res.Images, 1, "the removed image of the worker is left out")
+	require.Len(t, res.Networks, 1)
+	require.Len(t, res.Volumes, 1)
+
+	p, err := inspect.Compose(res, parser.WithTarget(""))
+	require.NoError(t, err)
+	require.Equal(t, `services:
+    web:
+        container_name: web
+        ports:
+            - 8080:80
+        volumes:
+            - data:/usr/share/nginx/html:ro
+        networks:
+            backend:
+                ipv4_address: 172.28.0.10
+        restart: unless-stopped
+        image: nginx:1.25
+    worker:
+        container_name: worker
+        environment:
+            QUEUE: jobs
+        volumes:
+            - data:/data
+        networks:
+            backend: {}
+        image: worker:latest
+        command:
+            - run
+networks:
+    backend:
+        name: backend
+        ipam:
+            config:
+                - subnet: 172.28.0.0/16
+                  gateway: 172.28.0.1
+        labels:
+            team: web
+volumes:
+    data:
+        name: data
+        labels:
+            backup: daily
+`, p.String())
+}
+
+func TestClientErrors(t *testing.T) {
+	client, err := NewClient(fakeEngine(t))
+	require.NoError(t, err)
+
+	_, err = client.Container(context.Background(), "missing")
+	require.True(t, IsNotFound(err))
+	require.EqualError(t, err, "docker engine API: No such object: missing")
+
+	_, err = client.Capture(context.Background(), nil, Filters{"label": {"env=none"}})
+	require.EqualError(t, err, "no running containers match the filters")
+
+	_, err = NewClient("npipe:////./pipe/docker_engine")
+	require.ErrorContains(t, err, "unsupported docker host")
+
+	t.Setenv("DOCKER_HOST", "")
+	client, err = NewClient("")
+	require.NoError(t, err)
+	require.Equal(t, "http://docker", client.base)
+}
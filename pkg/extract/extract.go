@@ -0,0 +1,186 @@
+// Package extract finds docker run, docker build, docker network create and docker volume create
+// commands in documentation, shell scripts and Dockerfiles.
+package extract
+
+import (
+	"bufio"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Command is a docker command found in a file.
+type Command struct {
+	// File is the name of the file and Line the line the command starts on, counting from 1.
+	File string
+	Line int
+	// Command is the docker command joined into a single line, eg: docker run -d nginx.
+	Command string
+}
+
+// Docker commands found in files.
+const (
+	DockerRun           = "docker run"
+	DockerBuild         = "docker build"
+	DockerNetworkCreate = "docker network create"
+	DockerVolumeCreate  = "docker volume create"
+)
+
+// fileType selects the lines of a file which are searched for commands.
+type fileType int
+
+const (
+	// shellFile is a shell script or any other file. All lines are searched,
+	// including comments, which often hold usage examples.
+	shellFile fileType = iota
+	// markdownFile is a Markdown document. Only the lines of fenced code blocks are searched.
+	markdownFile
+)
+
+// dockerCommand matches the beginning of a docker run, docker build, docker network create or
+// docker volume create command, optionally run with sudo.
+var dockerCommand = regexp.MustCompile(`(?:^|[\s;&|(])((?:sudo\s+)?docker\s+(?:(?:container\s+)?(run)|(network|volume)\s+create|(?:image\s+|buildx\s+|builder\s+)?(build)))(?:\s|$)`)
+
+// prompts are the shell prompts stripped from the beginning of the lines.
+var prompts = []string{"$ ", "% ", "> "}
+
+// Extract returns the docker run, docker build, docker network create and docker volume create commands
+// in the file read from r. Builds with docker image build or docker buildx build are returned as docker build.
+// The name selects how the file is searched: only the fenced code blocks of Markdown files
+// are searched, all lines of other files are. Comment markers, shell prompts like "$ "
+// and backslash line continuations are removed from the commands.
+func Extract(name string, r io.Reader) ([]Command, error) {
+	typ := shellFile
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".md", ".markdown", ".mdx":
+		typ = markdownFile
+	}
+
+	var commands []Command
+	var pending strings.Builder
+	start := 0
+	flush := func() {
+		for _, command := range find(pending.String(), true) {
+			commands = append(commands, Command{File: name, Line: start, Command: command})
+		}
+		pending.Reset()
+	}
+
+	var fence string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if typ == markdownFile {
+			marker := fenceMarker(line)
+			switch {
+			case fence == "" && marker != "":
+				fence = marker
+				continue
+			case fence != "" && line == marker && marker[0] == fence[0] && len(marker) >= len(fence):
+				fence = ""
+				flush()
+				continue
+			case fence == "":
+				continue
+			}
+		}
+
+		line = normalize(line)
+		if pending.Len() == 0 {
+			start = n
+		} else {
+			pending.WriteByte(' ')
+		}
+		if continued := strings.TrimSuffix(line, `\`); continued != line && !strings.HasSuffix(continued, `\`) {
+			pending.WriteString(strings.TrimSpace(continued))
+			continue
+		}
+		pending.WriteString(line)
+		flush()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	flush()
+	return commands, nil
+}
+
+// fenceMarker returns the ``` or ~~~ marker opening or closing a fenced code block, or an empty string.
+func fenceMarker(line string) string {
+	for _, c := range []string{"`", "~"} {
+		if strings.HasPrefix(line, c+c+c) {
+			return line[:len(line)-len(strings.TrimLeft(line, c))]
+		}
+	}
+	return ""
+}
+
+// normalize removes comment markers and shell prompts from the beginning of a line.
+func normalize(line string) string {
+	for {
+		trimmed := line
+		if strings.HasPrefix(trimmed, "#") {
+			trimmed = strings.TrimLeft(trimmed, "#")
+		}
+		for _, prompt := range prompts {
+			trimmed = strings.TrimPrefix(trimmed, prompt)
+		}
+		trimmed = strings.TrimSpace(trimmed)
+		if trimmed == line {
+			return line
+		}
+		line = trimmed
+	}
+}
+
+// Find returns the docker run commands in a shell command line. A command ends at the end of the line,
+// or at an unquoted shell operator, closing parenthesis, backtick or comment.
+func Find(line string) []string {
+	return find(line, false)
+}
+
+// find returns the docker run commands in a shell command line, and the docker build,
+// docker network create and docker volume create commands if all is set.
+func find(line string, all bool) []string {
+	var commands []string
+	for _, loc := range dockerCommand.FindAllStringSubmatchIndex(line, -1) {
+		prefix := DockerRun
+		switch {
+		case loc[4] >= 0:
+		case !all:
+			continue
+		case loc[6] >= 0:
+			prefix = "docker " + line[loc[6]:loc[7]] + " create"
+		default:
+			prefix = DockerBuild
+		}
+		rest := line[loc[3]:]
+		end := len(rest)
+		var quote byte
+	scan:
+		for i := 0; i < len(rest); i++ {
+			c := rest[i]
+			switch {
+			case quote != 0:
+				if c == quote {
+					quote = 0
+				}
+			case c == '"' || c == '\'':
+				quote = c
+			case c == ';' || c == '&' || c == '|' || c == ')' || c == '`':
+				end = i
+				break scan
+			case c == '#' && i > 0 && (rest[i-1] == ' ' || rest[i-1] == '\t'):
+				end = i
+				break scan
+			}
+		}
+		if args := strings.TrimSpace(rest[:end]); args != "" {
+			commands = append(commands, prefix+" "+args)
+		}
+	}
+	return commands
+}
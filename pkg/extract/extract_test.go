@@ -0,0 +1,69 @@
+package extract
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtract(t *testing.T) {
+	tests := []struct {
+		file string
+		want []Command
+	}{
+		{
+			file: "README.md",
+			want: []Command{
+				{Line: 6, Command: `docker run -d --name app -p 8080:80 -e "GREETING=hello  world" example/app:1.0`},
+				{Line: 13, Command: "docker run --rm -it alpine sh"},
+				{Line: 18, Command: "docker run nested"},
+			},
+		},
+		{
+			file: "install.sh",
+			want: []Command{
+				{Line: 5, Command: "docker run -v /data:/data example/installer"},
+				{Line: 7, Command: "docker network create app"},
+				{Line: 8, Command: "docker run -d --network app redis:7"},
+				{Line: 9, Command: "docker run --rm example/migrate"},
+				{Line: 9, Command: "docker run -d example/worker"},
+				{Line: 9, Command: "docker build --platform linux/amd64 -t example/worker ."},
+				{Line: 10, Command: "docker volume create --driver local -o type=tmpfs -o device=tmpfs data"},
+			},
+		},
+		{
+			file: "Dockerfile",
+			want: []Command{
+				{Line: 1, Command: "docker build -t example/app ."},
+				{Line: 2, Command: "docker run -p 80:80 example/app"},
+				{Line: 4, Command: "docker run example/tool"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			require.NoError(t, err)
+			defer f.Close()
+
+			commands, err := Extract(tt.file, f)
+			require.NoError(t, err)
+			for i := range tt.want {
+				tt.want[i].File = tt.file
+			}
+			require.Equal(t, tt.want, commands)
+		})
+	}
+}
+
+func TestFind(t *testing.T) {
+	require.Equal(t, []string{"docker run -e 'A=1;2' alpine", "docker run redis"},
+		Find(`docker run -e 'A=1;2' alpine && sudo docker run redis # cache`))
+	require.Empty(t, Find("docker runner start"))
+	require.Empty(t, Find("docker run"))
+	require.Empty(t, Find("docker network create app"))
+	require.Empty(t, Find("docker buildx build -t app ."))
+}
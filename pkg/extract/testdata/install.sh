@@ -0,0 +1,10 @@
+#!/bin/sh
+set -e
+
+# Usage:
+#   docker run -v /data:/data \
+#     example/installer
+docker network create app || true
+id=$(docker run -d --network app redis:7) && echo "$id"
+docker run --rm example/migrate; docker run -d example/worker; docker buildx build --platform linux/amd64 -t example/worker .
+sudo docker volume create --driver local -o type=tmpfs -o device=tmpfs data
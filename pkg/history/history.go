@@ -0,0 +1,195 @@
+// Package history reads the docker run commands from bash, zsh and fish history files.
+package history
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/profclems/compozify/pkg/extract"
+)
+
+// Shell is the shell which wrote a history file.
+type Shell string
+
+// Supported shells.
+const (
+	Bash Shell = "bash"
+	Zsh  Shell = "zsh"
+	Fish Shell = "fish"
+)
+
+// Shells are the shells with a supported history format.
+var Shells = []Shell{Bash, Zsh, Fish}
+
+// DetectShell returns the shell which writes the history file with the given name,
+// eg: .zsh_history for zsh. Bash is returned for unknown names.
+func DetectShell(name string) Shell {
+	base := strings.ToLower(filepath.Base(name))
+	switch {
+	case strings.Contains(base, "fish"):
+		return Fish
+	case strings.Contains(base, "zsh"), strings.Contains(base, "zhistory"):
+		return Zsh
+	}
+	return Bash
+}
+
+// Entry is a docker run command read from a history file.
+type Entry struct {
+	// Command is the docker run command.
+	Command string
+	// Time is when the command was run, zero when the history has no timestamps.
+	Time time.Time
+}
+
+// Read returns the unique docker run commands of the history written by the shell, most recent first.
+// The time of a command run several times is the time it was run last.
+func Read(r io.Reader, shell Shell) ([]Entry, error) {
+	var commands []Entry
+	var err error
+	switch shell {
+	case Bash:
+		commands, err = readBash(r)
+	case Zsh:
+		commands, err = readZsh(r)
+	case Fish:
+		commands, err = readFish(r)
+	default:
+		return nil, fmt.Errorf("unsupported shell %q", shell)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []Entry
+	seen := map[string]bool{}
+	for i := len(commands) - 1; i >= 0; i-- {
+		for _, command := range extract.Find(commands[i].Command) {
+			if !seen[command] {
+				seen[command] = true
+				entries = append(entries, Entry{Command: command, Time: commands[i].Time})
+			}
+		}
+	}
+	return entries, nil
+}
+
+// readBash reads a bash history. Commands are preceded by a #<unix time> line when
+// HISTTIMEFORMAT is set, and continue on the next line after a trailing backslash.
+func readBash(r io.Reader) ([]Entry, error) {
+	var commands []Entry
+	var when time.Time
+	var pending string
+	err := scanLines(r, func(line string) {
+		if pending == "" && strings.HasPrefix(line, "#") {
+			if t, ok := unixTime(line[1:]); ok {
+				when = t
+				return
+			}
+		}
+		if strings.HasSuffix(line, `\`) {
+			pending += strings.TrimSuffix(line, `\`) + " "
+			return
+		}
+		commands = append(commands, Entry{Command: pending + line, Time: when})
+		pending, when = "", time.Time{}
+	})
+	return commands, err
+}
+
+// readZsh reads a zsh history in the simple or the extended format, eg: ": 1700000000:0;docker run nginx".
+// Multi-line commands continue on the next line after a trailing backslash.
+func readZsh(r io.Reader) ([]Entry, error) {
+	var commands []Entry
+	var pending *Entry
+	err := scanLines(r, func(line string) {
+		line = unmetafy(line)
+		if pending == nil {
+			pending = &Entry{Command: line}
+			if strings.HasPrefix(line, ": ") {
+				if meta, command, ok := strings.Cut(line[2:], ";"); ok {
+					start, _, _ := strings.Cut(meta, ":")
+					if t, ok := unixTime(start); ok {
+						pending = &Entry{Command: command, Time: t}
+					}
+				}
+			}
+		} else {
+			pending.Command += line
+		}
+		if strings.HasSuffix(pending.Command, `\`) {
+			pending.Command = strings.TrimSuffix(pending.Command, `\`) + " "
+			return
+		}
+		commands = append(commands, *pending)
+		pending = nil
+	})
+	if pending != nil {
+		commands = append(commands, *pending)
+	}
+	return commands, err
+}
+
+// readFish reads a fish history, a list of "- cmd: COMMAND" entries with a "when: UNIX TIME" attribute.
+func readFish(r io.Reader) ([]Entry, error) {
+	var commands []Entry
+	err := scanLines(r, func(line string) {
+		if command, ok := strings.CutPrefix(line, "- cmd: "); ok {
+			commands = append(commands, Entry{Command: fishUnescape(command)})
+			return
+		}
+		if when, ok := strings.CutPrefix(strings.TrimSpace(line), "when: "); ok && len(commands) > 0 {
+			if t, ok := unixTime(when); ok {
+				commands[len(commands)-1].Time = t
+			}
+		}
+	})
+	return commands, err
+}
+
+func scanLines(r io.Reader, fn func(line string)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fn(scanner.Text())
+	}
+	return scanner.Err()
+}
+
+func unixTime(s string) (time.Time, bool) {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(s), 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(seconds, 0), true
+}
+
+// unmetafy decodes the bytes zsh escapes in its history with the 0x83 meta byte.
+func unmetafy(s string) string {
+	if !strings.Contains(s, "\x83") {
+		return s
+	}
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] == 0x83 && i+1 < len(s) {
+			i++
+			b = append(b, s[i]^32)
+			continue
+		}
+		b = append(b, s[i])
+	}
+	return string(b)
+}
+
+// fishUnescape decodes the newlines and backslashes fish escapes in its history.
+// Escaped newlines of multi-line commands are joined with spaces.
+func fishUnescape(s string) string {
+	s = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(s)
+	s = strings.ReplaceAll(s, "\\\n", " ")
+	return strings.ReplaceAll(s, "\n", "; ")
+}
@@ -0,0 +1,61 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRead(t *testing.T) {
+	tests := []struct {
+		file  string
+		shell Shell
+		want  []Entry
+	}{
+		{
+			file:  "bash_history",
+			shell: Bash,
+			want: []Entry{
+				{Command: "docker run -d --name web -p 8080:80 nginx:1.25"},
+				{Command: "docker run --rm    -e FOO=bar alpine env", Time: time.Unix(1700000100, 0)},
+			},
+		},
+		{
+			file:  ".zsh_history",
+			shell: Zsh,
+			want: []Entry{
+				{Command: "docker run -e NAME=voilà alpine", Time: time.Unix(1700000300, 0)},
+				{Command: "docker run --rm    -v data:/data    alpine ls /data", Time: time.Unix(1700000200, 0)},
+				{Command: "docker run -d redis:7", Time: time.Unix(1700000000, 0)},
+			},
+		},
+		{
+			file:  "fish_history",
+			shell: Fish,
+			want: []Entry{
+				{Command: "docker run --rm    -e A=1 alpine", Time: time.Unix(1700000100, 0)},
+				{Command: "docker run -d postgres:16", Time: time.Unix(1700000000, 0)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			require.Equal(t, tt.shell, DetectShell(tt.file))
+
+			f, err := os.Open(filepath.Join("testdata", tt.file))
+			require.NoError(t, err)
+			defer f.Close()
+
+			entries, err := Read(f, tt.shell)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, entries)
+		})
+	}
+
+	_, err := Read(nil, "csh")
+	require.EqualError(t, err, `unsupported shell "csh"`)
+}
@@ -0,0 +1,363 @@
+package inspect
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultShmSize is the size of /dev/shm docker uses when --shm-size is not set.
+const defaultShmSize = 64 << 20
+
+// composeLabelPrefix is the prefix of the labels docker compose adds to the containers it creates.
+const composeLabelPrefix = "com.docker.compose."
+
+// RunArgs returns the docker run arguments, the flags followed by the image and the command,
+// which create a container like c. Flags are written as --name=value.
+// Settings equal to the defaults of the image are left out when the image is not nil,
+// as are the settings docker fills in itself, like the hostname and default network.
+func RunArgs(c *Container, image *Image) []string {
+	var defaults Config
+	if image != nil {
+		defaults = image.Config
+	}
+	cfg, host := c.Config, c.HostConfig
+
+	var args runArgs
+	args.add("name", c.ContainerName())
+	if cfg.Hostname != "" && !strings.HasPrefix(c.ID, cfg.Hostname) {
+		args.add("hostname", cfg.Hostname)
+	}
+	args.add("domainname", cfg.Domainname)
+	if cfg.User != defaults.User {
+		args.add("user", cfg.User)
+	}
+	if cfg.WorkingDir != defaults.WorkingDir {
+		args.add("workdir", cfg.WorkingDir)
+	}
+	args.bool("interactive", cfg.OpenStdin)
+	args.bool("tty", cfg.Tty)
+	args.bool("rm", host.AutoRemove)
+	for _, env := range cfg.Env {
+		if !contains(defaults.Env, env) {
+			args.add("env", env)
+		}
+	}
+	for _, k := range sortedKeys(cfg.Labels) {
+		if strings.HasPrefix(k, composeLabelPrefix) {
+			continue
+		}
+		if v, ok := defaults.Labels[k]; !ok || v != cfg.Labels[k] {
+			args.add("label", k+"="+cfg.Labels[k])
+		}
+	}
+
+	ports := make([]string, 0, len(host.PortBindings))
+	for port := range host.PortBindings {
+		ports = append(ports, port)
+	}
+	sort.Slice(ports, func(i, j int) bool { return portLess(ports[i], ports[j]) })
+	for _, port := range ports {
+		containerPort := strings.TrimSuffix(port, "/tcp")
+		for _, binding := range host.PortBindings[port] {
+			spec := containerPort
+			if binding.HostPort != "" {
+				spec = binding.HostPort + ":" + spec
+			}
+			if binding.HostIP != "" && binding.HostIP != "0.0.0.0" && binding.HostIP != "::" {
+				hostIP := binding.HostIP
+				if strings.Contains(hostIP, ":") {
+					hostIP = "[" + hostIP + "]"
+				}
+				if binding.HostPort == "" {
+					spec = ":" + spec
+				}
+				spec = hostIP + ":" + spec
+			}
+			args.add("publish", spec)
+		}
+	}
+	exposed := make([]string, 0, len(cfg.ExposedPorts))
+	for port := range cfg.ExposedPorts {
+		if _, ok := defaults.ExposedPorts[port]; ok {
+			continue
+		}
+		if _, ok := host.PortBindings[port]; ok {
+			continue
+		}
+		exposed = append(exposed, port)
+	}
+	sort.Slice(exposed, func(i, j int) bool { return portLess(exposed[i], exposed[j]) })
+	for _, port := range exposed {
+		args.add("expose", strings.TrimSuffix(port, "/tcp"))
+	}
+
+	for _, bind := range host.Binds {
+		args.add("volume", bind)
+	}
+	for _, m := range host.Mounts {
+		spec := "type=" + m.Type
+		if m.Source != "" {
+			spec += ",source=" + m.Source
+		}
+		spec += ",target=" + m.Target
+		if m.ReadOnly {
+			spec += ",readonly"
+		}
+		args.add("mount", spec)
+	}
+	for _, path := range sortedKeys(host.Tmpfs) {
+		if opts := host.Tmpfs[path]; opts != "" {
+			path += ":" + opts
+		}
+		args.add("tmpfs", path)
+	}
+	args.add("volumes-from", host.VolumesFrom...)
+
+	switch mode := host.NetworkMode; mode {
+	case "", "default", "bridge":
+	default:
+		args.add("network", mode)
+		if n := c.NetworkSettings.Networks[mode]; n != nil {
+			if n.IPAMConfig != nil {
+				args.add("ip", n.IPAMConfig.IPv4Address)
+				args.add("ip6", n.IPAMConfig.IPv6Address)
+			}
+			for _, alias := range n.Aliases {
+				if alias != c.ContainerName() && !strings.HasPrefix(c.ID, alias) {
+					args.add("network-alias", alias)
+				}
+			}
+		}
+	}
+	for _, link := range host.Links {
+		// links are stored as /db:/web/db
+		name, alias, _ := strings.Cut(link, ":")
+		args.add("link", strings.TrimPrefix(name, "/")+":"+alias[strings.LastIndex(alias, "/")+1:])
+	}
+	args.add("dns", host.DNS...)
+	args.add("dns-search", host.DNSSearch...)
+	args.add("dns-option", host.DNSOptions...)
+	args.add("add-host", host.ExtraHosts...)
+
+	if policy := host.RestartPolicy; policy.Name != "" && policy.Name != "no" {
+		restart := policy.Name
+		if policy.MaximumRetryCount > 0 {
+			restart += ":" + strconv.Itoa(policy.MaximumRetryCount)
+		}
+		args.add("restart", restart)
+	}
+	if h := cfg.Healthcheck; h != nil && !healthcheckEqual(h, defaults.Healthcheck) {
+		switch {
+		case len(h.Test) == 0:
+		case h.Test[0] == "NONE":
+			args.bool("no-healthcheck", true)
+		default:
+			test := h.Test[1:]
+			if h.Test[0] == "CMD-SHELL" {
+				args.add("health-cmd", strings.Join(test, " "))
+			} else {
+				args.add("health-cmd", shellJoin(test))
+			}
+			args.duration("health-interval", h.Interval)
+			args.duration("health-timeout", h.Timeout)
+			args.duration("health-start-period", h.StartPeriod)
+			if h.Retries > 0 {
+				args.add("health-retries", strconv.Itoa(h.Retries))
+			}
+		}
+	}
+
+	if host.NanoCPUs > 0 {
+		args.add("cpus", strconv.FormatFloat(float64(host.NanoCPUs)/1e9, 'f', -1, 64))
+	}
+	if host.CPUShares > 0 {
+		args.add("cpu-shares", strconv.FormatInt(host.CPUShares, 10))
+	}
+	args.add("cpuset-cpus", host.CpusetCpus)
+	if host.Memory > 0 {
+		args.add("memory", formatBytes(host.Memory))
+	}
+	if host.MemoryReservation > 0 {
+		args.add("memory-reservation", formatBytes(host.MemoryReservation))
+	}
+	// docker sets the swap limit to twice the memory limit by default
+	if host.MemorySwap == -1 {
+		args.add("memory-swap", "-1")
+	} else if host.MemorySwap > 0 && host.MemorySwap != 2*host.Memory {
+		args.add("memory-swap", formatBytes(host.MemorySwap))
+	}
+	if host.PidsLimit != nil && *host.PidsLimit > 0 {
+		args.add("pids-limit", strconv.FormatInt(*host.PidsLimit, 10))
+	}
+	if host.ShmSize > 0 && host.ShmSize != defaultShmSize {
+		args.add("shm-size", formatBytes(host.ShmSize))
+	}
+	for _, u := range host.Ulimits {
+		args.add("ulimit", fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard))
+	}
+
+	args.add("cap-add", host.CapAdd...)
+	args.add("cap-drop", host.CapDrop...)
+	args.bool("privileged", host.Privileged)
+	args.bool("read-only", host.ReadonlyRootfs)
+	args.bool("init", host.Init != nil && *host.Init)
+	args.add("security-opt", host.SecurityOpt...)
+	args.add("group-add", host.GroupAdd...)
+	for _, d := range host.Devices {
+		device := d.PathOnHost
+		if d.PathInContainer != "" && d.PathInContainer != d.PathOnHost || d.CgroupPermissions != "" && d.CgroupPermissions != "rwm" {
+			device += ":" + d.PathInContainer
+		}
+		if d.CgroupPermissions != "" && d.CgroupPermissions != "rwm" {
+			device += ":" + d.CgroupPermissions
+		}
+		args.add("device", device)
+	}
+	for _, k := range sortedKeys(host.Sysctls) {
+		args.add("sysctl", k+"="+host.Sysctls[k])
+	}
+
+	switch host.IpcMode {
+	case "", "private", "shareable":
+	default:
+		args.add("ipc", host.IpcMode)
+	}
+	args.add("pid", host.PidMode)
+	args.add("uts", host.UTSMode)
+	args.add("userns", host.UsernsMode)
+	if host.Runtime != "runc" {
+		args.add("runtime", host.Runtime)
+	}
+	// json-file without options is the default logging driver of the docker daemon
+	if log := host.LogConfig; log.Type != "" && (log.Type != "json-file" || len(log.Config) > 0) {
+		args.add("log-driver", log.Type)
+		for _, k := range sortedKeys(log.Config) {
+			args.add("log-opt", k+"="+log.Config[k])
+		}
+	}
+	if cfg.StopSignal != defaults.StopSignal {
+		args.add("stop-signal", cfg.StopSignal)
+	}
+	if cfg.StopTimeout != nil {
+		args.add("stop-timeout", strconv.Itoa(*cfg.StopTimeout))
+	}
+
+	// docker run resets the command of the image when the entrypoint is set,
+	// so the command is kept whenever the entrypoint differs from the image.
+	entrypoint := !equal(cfg.Entrypoint, defaults.Entrypoint)
+	var command []string
+	if entrypoint && len(cfg.Entrypoint) > 0 {
+		args.add("entrypoint", cfg.Entrypoint[0])
+		command = append(command, cfg.Entrypoint[1:]...)
+	}
+	if entrypoint || image == nil || !equal(cfg.Cmd, defaults.Cmd) {
+		command = append(command, cfg.Cmd...)
+	}
+
+	return append(append(args, c.ImageName()), command...)
+}
+
+// runArgs collects docker run flags.
+type runArgs []string
+
+func (a *runArgs) add(name string, values ...string) {
+	for _, v := range values {
+		if v != "" {
+			*a = append(*a, "--"+name+"="+v)
+		}
+	}
+}
+
+func (a *runArgs) bool(name string, value bool) {
+	if value {
+		*a = append(*a, "--"+name)
+	}
+}
+
+func (a *runArgs) duration(name string, ns int64) {
+	if ns > 0 {
+		a.add(name, time.Duration(ns).String())
+	}
+}
+
+// formatBytes formats a size in bytes with the largest unit it is a multiple of, eg: 512m.
+func formatBytes(b int64) string {
+	for _, unit := range []struct {
+		size   int64
+		suffix string
+	}{{1 << 30, "g"}, {1 << 20, "m"}, {1 << 10, "k"}} {
+		if b%unit.size == 0 {
+			return strconv.FormatInt(b/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(b, 10)
+}
+
+// shellJoin joins the arguments of an exec form health check into a shell command.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg != "" && !strings.ContainsAny(arg, " \t\n\"'\\$`;&|<>()*?") {
+			quoted[i] = arg
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// portLess orders ports like 80/tcp numerically and then by protocol.
+func portLess(a, b string) bool {
+	portA, protoA, _ := strings.Cut(a, "/")
+	portB, protoB, _ := strings.Cut(b, "/")
+	numA, errA := strconv.Atoi(portA)
+	numB, errB := strconv.Atoi(portB)
+	if errA == nil && errB == nil && numA != numB {
+		return numA < numB
+	}
+	if portA != portB {
+		return portA < portB
+	}
+	return protoA < protoB
+}
+
+func healthcheckEqual(a, b *Healthcheck) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return equal(a.Test, b.Test) && a.Interval == b.Interval && a.Timeout == b.Timeout &&
+		a.StartPeriod == b.StartPeriod && a.Retries == b.Retries
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,218 @@
+package inspect
+
+import (
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// Compose converts the containers of r into the services of a single docker compose file,
+// named after the containers. The user defined networks the containers are connected to and
+// the named volumes they mount are added as top-level networks and volumes, recreated from r
+// when it includes them and declared as external otherwise.
+func Compose(r *Result, opts ...parser.Option) (*parser.Parser, error) {
+	if len(r.Containers) == 0 {
+		return nil, errors.New("no containers found in docker inspect output")
+	}
+
+	var compose *parser.Parser
+	var networks, volumes []string
+	static := map[string]bool{}
+	for _, c := range r.Containers {
+		args := RunArgs(c, r.Image(c.Image))
+		p, err := parser.NewArgs(args, append(opts[:len(opts):len(opts)], parser.WithServiceName(c.ContainerName()))...)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.Parse(); err != nil {
+			return nil, err
+		}
+
+		s := p.Service()
+		if serviceNetworks := ServiceNetworks(c); len(serviceNetworks) > 0 {
+			s.SetNetworks(serviceNetworks)
+			for _, name := range sortedNetworks(serviceNetworks) {
+				networks = appendUnique(networks, name)
+				if n := serviceNetworks[name]; n != nil && (n.Ipv4Address != "" || n.Ipv6Address != "") {
+					static[name] = true
+				}
+			}
+		}
+		for _, name := range NamedVolumes(c) {
+			volumes = appendUnique(volumes, name)
+		}
+
+		if compose == nil {
+			compose = p
+			continue
+		}
+		compose.Project().Services = append(compose.Project().Services, s)
+	}
+
+	project := compose.Project()
+	for _, name := range networks {
+		network := &parser.NetworkConfig{Name: name, External: true}
+		if n := r.Network(name); n != nil {
+			network = NetworkConfig(n, static[name])
+		}
+		project.Networks = append(project.Networks, network)
+	}
+	for _, name := range volumes {
+		volume := &parser.VolumeConfig{Name: name, External: true}
+		if v := r.Volume(name); v != nil {
+			volume = VolumeConfig(v)
+		}
+		project.Volumes = append(project.Volumes, volume)
+	}
+
+	if _, err := compose.Render(); err != nil {
+		return nil, err
+	}
+	return compose, nil
+}
+
+// ServiceNetworks returns the user defined networks c is connected to with its
+// aliases and static addresses. The aliases docker adds itself are left out.
+func ServiceNetworks(c *Container) map[string]*parser.ServiceNetworkConfig {
+	switch mode := c.HostConfig.NetworkMode; {
+	case mode == "host", mode == "none", strings.HasPrefix(mode, "container:"):
+		return nil
+	}
+
+	networks := map[string]*parser.ServiceNetworkConfig{}
+	for name, endpoint := range c.NetworkSettings.Networks {
+		if !IsUserNetwork(name) {
+			continue
+		}
+		network := &parser.ServiceNetworkConfig{}
+		if endpoint != nil {
+			if endpoint.IPAMConfig != nil {
+				network.Ipv4Address = endpoint.IPAMConfig.IPv4Address
+				network.Ipv6Address = endpoint.IPAMConfig.IPv6Address
+			}
+			for _, alias := range endpoint.Aliases {
+				if alias != c.ContainerName() && !strings.HasPrefix(c.ID, alias) {
+					network.Aliases = append(network.Aliases, alias)
+				}
+			}
+		}
+		if network.Ipv4Address == "" && network.Ipv6Address == "" && len(network.Aliases) == 0 {
+			network = nil
+		}
+		networks[name] = network
+	}
+	if len(networks) == 0 {
+		return nil
+	}
+	return networks
+}
+
+// NamedVolumes returns the names of the volumes c mounts, excluding bind mounts and anonymous volumes.
+func NamedVolumes(c *Container) []string {
+	var volumes []string
+	for _, bind := range c.HostConfig.Binds {
+		source, _, _ := strings.Cut(bind, ":")
+		if source != "" && !strings.ContainsAny(source[:1], "/.~") {
+			volumes = appendUnique(volumes, source)
+		}
+	}
+	for _, m := range c.HostConfig.Mounts {
+		if m.Type == "volume" && m.Source != "" {
+			volumes = appendUnique(volumes, m.Source)
+		}
+	}
+	return volumes
+}
+
+// IsUserNetwork reports whether the network is a user defined network and not one of the
+// networks docker creates itself.
+func IsUserNetwork(name string) bool {
+	switch name {
+	case "", "default", "bridge", "host", "none":
+		return false
+	}
+	return true
+}
+
+// NetworkConfig returns the top-level docker compose network recreating n with the same name.
+// The address pools are only kept when static is set, as they are assigned by docker otherwise.
+func NetworkConfig(n *Network, static bool) *parser.NetworkConfig {
+	network := &parser.NetworkConfig{
+		Name:        n.Name,
+		NetworkName: n.Name,
+		DriverOpts:  mapping(n.Options),
+		Attachable:  n.Attachable,
+		EnableIPv6:  n.EnableIPv6,
+		Internal:    n.Internal,
+		Labels:      labels(n.Labels),
+	}
+	if n.Driver != "bridge" {
+		network.Driver = n.Driver
+	}
+	if static && len(n.IPAM.Config) > 0 {
+		network.Ipam = &parser.IPAMConfig{}
+		if n.IPAM.Driver != "default" {
+			network.Ipam.Driver = n.IPAM.Driver
+		}
+		for _, pool := range n.IPAM.Config {
+			network.Ipam.Config = append(network.Ipam.Config, &parser.IPAMPool{
+				Subnet:       pool.Subnet,
+				IPRange:      pool.IPRange,
+				Gateway:      pool.Gateway,
+				AuxAddresses: mapping(pool.AuxAddress),
+			})
+		}
+	}
+	return network
+}
+
+// VolumeConfig returns the top-level docker compose volume recreating v with the same name.
+func VolumeConfig(v *Volume) *parser.VolumeConfig {
+	volume := &parser.VolumeConfig{
+		Name:       v.Name,
+		VolumeName: v.Name,
+		DriverOpts: mapping(v.Options),
+		Labels:     labels(v.Labels),
+	}
+	if v.Driver != "local" {
+		volume.Driver = v.Driver
+	}
+	return volume
+}
+
+// labels returns the labels without the ones docker compose adds itself.
+func labels(m map[string]string) parser.Mapping {
+	var labels parser.Mapping
+	for _, k := range sortedKeys(m) {
+		if !strings.HasPrefix(k, composeLabelPrefix) {
+			labels = append(labels, parser.KeyValue{Key: k, Value: m[k]})
+		}
+	}
+	return labels
+}
+
+func mapping(m map[string]string) parser.Mapping {
+	var mapping parser.Mapping
+	for _, k := range sortedKeys(m) {
+		mapping = append(mapping, parser.KeyValue{Key: k, Value: m[k]})
+	}
+	return mapping
+}
+
+func sortedNetworks(networks map[string]*parser.ServiceNetworkConfig) []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func appendUnique(list []string, s string) []string {
+	if contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
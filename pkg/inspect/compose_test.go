@@ -0,0 +1,34 @@
+package inspect
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+func TestCompose(t *testing.T) {
+	f, err := os.Open("testdata/inspect.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := Decode(f)
+	require.NoError(t, err)
+
+	p, err := Compose(res, parser.WithTarget(""))
+	require.NoError(t, err)
+	project := p.Project()
+	require.Len(t, project.Services, 2)
+	require.Equal(t, map[string]*parser.ServiceNetworkConfig{"backend": {Aliases: []string{"proxy"}}}, project.Service("web").Networks)
+	require.Empty(t, project.Service("web").NetworkMode)
+	require.Nil(t, project.Service("cache").Networks)
+
+	// the network and volume are not part of the output
+	require.Equal(t, []*parser.NetworkConfig{{Name: "backend", External: true}}, project.Networks)
+	require.Equal(t, []*parser.VolumeConfig{{Name: "data", External: true}}, project.Volumes)
+
+	_, err = Compose(&Result{})
+	require.EqualError(t, err, "no containers found in docker inspect output")
+}
@@ -0,0 +1,302 @@
+// Package inspect converts the output of docker inspect into docker run arguments
+// and docker compose files.
+package inspect
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Container is the part of the docker inspect output of a container used to recreate it.
+type Container struct {
+	ID              string          `json:"Id"`
+	Name            string          `json:"Name"`
+	Image           string          `json:"Image"`
+	Config          Config          `json:"Config"`
+	HostConfig      HostConfig      `json:"HostConfig"`
+	NetworkSettings NetworkSettings `json:"NetworkSettings"`
+}
+
+// Image is the part of the docker inspect output of an image holding the defaults of its containers.
+type Image struct {
+	ID       string   `json:"Id"`
+	RepoTags []string `json:"RepoTags"`
+	Config   Config   `json:"Config"`
+}
+
+// Config is the configuration of a container or the container defaults of an image.
+type Config struct {
+	Hostname     string              `json:"Hostname"`
+	Domainname   string              `json:"Domainname"`
+	User         string              `json:"User"`
+	ExposedPorts map[string]struct{} `json:"ExposedPorts"`
+	Tty          bool                `json:"Tty"`
+	OpenStdin    bool                `json:"OpenStdin"`
+	Env          []string            `json:"Env"`
+	Cmd          []string            `json:"Cmd"`
+	Healthcheck  *Healthcheck        `json:"Healthcheck"`
+	Image        string              `json:"Image"`
+	WorkingDir   string              `json:"WorkingDir"`
+	Entrypoint   []string            `json:"Entrypoint"`
+	Labels       map[string]string   `json:"Labels"`
+	StopSignal   string              `json:"StopSignal"`
+	StopTimeout  *int                `json:"StopTimeout"`
+}
+
+// Healthcheck is the health check of a container. Durations are in nanoseconds.
+type Healthcheck struct {
+	Test        []string `json:"Test"`
+	Interval    int64    `json:"Interval"`
+	Timeout     int64    `json:"Timeout"`
+	StartPeriod int64    `json:"StartPeriod"`
+	Retries     int      `json:"Retries"`
+}
+
+// HostConfig is the host configuration of a container.
+type HostConfig struct {
+	Binds             []string                 `json:"Binds"`
+	LogConfig         LogConfig                `json:"LogConfig"`
+	NetworkMode       string                   `json:"NetworkMode"`
+	PortBindings      map[string][]PortBinding `json:"PortBindings"`
+	RestartPolicy     RestartPolicy            `json:"RestartPolicy"`
+	AutoRemove        bool                     `json:"AutoRemove"`
+	VolumesFrom       []string                 `json:"VolumesFrom"`
+	CapAdd            []string                 `json:"CapAdd"`
+	CapDrop           []string                 `json:"CapDrop"`
+	DNS               []string                 `json:"Dns"`
+	DNSOptions        []string                 `json:"DnsOptions"`
+	DNSSearch         []string                 `json:"DnsSearch"`
+	ExtraHosts        []string                 `json:"ExtraHosts"`
+	GroupAdd          []string                 `json:"GroupAdd"`
+	IpcMode           string                   `json:"IpcMode"`
+	Links             []string                 `json:"Links"`
+	PidMode           string                   `json:"PidMode"`
+	Privileged        bool                     `json:"Privileged"`
+	ReadonlyRootfs    bool                     `json:"ReadonlyRootfs"`
+	SecurityOpt       []string                 `json:"SecurityOpt"`
+	Tmpfs             map[string]string        `json:"Tmpfs"`
+	UTSMode           string                   `json:"UTSMode"`
+	UsernsMode        string                   `json:"UsernsMode"`
+	ShmSize           int64                    `json:"ShmSize"`
+	Sysctls           map[string]string        `json:"Sysctls"`
+	Runtime           string                   `json:"Runtime"`
+	CPUShares         int64                    `json:"CpuShares"`
+	Memory            int64                    `json:"Memory"`
+	NanoCPUs          int64                    `json:"NanoCpus"`
+	CpusetCpus        string                   `json:"CpusetCpus"`
+	Devices           []Device                 `json:"Devices"`
+	MemoryReservation int64                    `json:"MemoryReservation"`
+	MemorySwap        int64                    `json:"MemorySwap"`
+	PidsLimit         *int64                   `json:"PidsLimit"`
+	Ulimits           []Ulimit                 `json:"Ulimits"`
+	Mounts            []Mount                  `json:"Mounts"`
+	Init              *bool                    `json:"Init"`
+}
+
+// LogConfig is the logging driver of a container.
+type LogConfig struct {
+	Type   string            `json:"Type"`
+	Config map[string]string `json:"Config"`
+}
+
+// PortBinding is a host port a container port is published on.
+type PortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string `json:"HostPort"`
+}
+
+// RestartPolicy is the restart policy of a container.
+type RestartPolicy struct {
+	Name              string `json:"Name"`
+	MaximumRetryCount int    `json:"MaximumRetryCount"`
+}
+
+// Device is a host device added to a container.
+type Device struct {
+	PathOnHost        string `json:"PathOnHost"`
+	PathInContainer   string `json:"PathInContainer"`
+	CgroupPermissions string `json:"CgroupPermissions"`
+}
+
+// Ulimit is a resource limit of a container.
+type Ulimit struct {
+	Name string `json:"Name"`
+	Soft int64  `json:"Soft"`
+	Hard int64  `json:"Hard"`
+}
+
+// Mount is a mount added with the --mount flag.
+type Mount struct {
+	Type     string `json:"Type"`
+	Source   string `json:"Source"`
+	Target   string `json:"Target"`
+	ReadOnly bool   `json:"ReadOnly"`
+}
+
+// NetworkSettings are the networks a container is connected to.
+type NetworkSettings struct {
+	Networks map[string]*EndpointSettings `json:"Networks"`
+}
+
+// EndpointSettings is the connection of a container to a network.
+type EndpointSettings struct {
+	IPAMConfig *EndpointIPAMConfig `json:"IPAMConfig"`
+	Aliases    []string            `json:"Aliases"`
+}
+
+// EndpointIPAMConfig are the static addresses of a container in a network.
+type EndpointIPAMConfig struct {
+	IPv4Address string `json:"IPv4Address"`
+	IPv6Address string `json:"IPv6Address"`
+}
+
+// Network is the part of the docker inspect output of a network used to recreate it.
+type Network struct {
+	Name       string            `json:"Name"`
+	ID         string            `json:"Id"`
+	Driver     string            `json:"Driver"`
+	EnableIPv6 bool              `json:"EnableIPv6"`
+	IPAM       IPAM              `json:"IPAM"`
+	Internal   bool              `json:"Internal"`
+	Attachable bool              `json:"Attachable"`
+	Options    map[string]string `json:"Options"`
+	Labels     map[string]string `json:"Labels"`
+}
+
+// IPAM is the IP address management of a network.
+type IPAM struct {
+	Driver string       `json:"Driver"`
+	Config []IPAMConfig `json:"Config"`
+}
+
+// IPAMConfig is an address pool of a network.
+type IPAMConfig struct {
+	Subnet     string            `json:"Subnet"`
+	IPRange    string            `json:"IPRange"`
+	Gateway    string            `json:"Gateway"`
+	AuxAddress map[string]string `json:"AuxiliaryAddresses"`
+}
+
+// Volume is the part of the docker inspect output of a volume used to recreate it.
+type Volume struct {
+	Name    string            `json:"Name"`
+	Driver  string            `json:"Driver"`
+	Labels  map[string]string `json:"Labels"`
+	Options map[string]string `json:"Options"`
+}
+
+// Result is the decoded output of docker inspect.
+type Result struct {
+	Containers []*Container
+	Images     []*Image
+	Networks   []*Network
+	Volumes    []*Volume
+}
+
+// Decode decodes the output of docker inspect, a JSON array of containers and images,
+// or a single object as returned by the Docker Engine API.
+// Networks and volumes are decoded as well, other objects are ignored.
+func Decode(r io.Reader) (*Result, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, errors.New("empty docker inspect output")
+	}
+
+	var objects []json.RawMessage
+	if b[0] == '{' {
+		objects = []json.RawMessage{b}
+	} else if err := json.Unmarshal(b, &objects); err != nil {
+		return nil, fmt.Errorf("failed to parse docker inspect output: %w", err)
+	}
+
+	res := &Result{}
+	for _, object := range objects {
+		var keys map[string]json.RawMessage
+		if err := json.Unmarshal(object, &keys); err != nil {
+			return nil, fmt.Errorf("failed to parse docker inspect output: %w", err)
+		}
+		switch {
+		case keys["State"] != nil:
+			var c Container
+			if err := json.Unmarshal(object, &c); err != nil {
+				return nil, fmt.Errorf("failed to parse container: %w", err)
+			}
+			res.Containers = append(res.Containers, &c)
+		case keys["RepoTags"] != nil || keys["RootFS"] != nil:
+			var image Image
+			if err := json.Unmarshal(object, &image); err != nil {
+				return nil, fmt.Errorf("failed to parse image: %w", err)
+			}
+			res.Images = append(res.Images, &image)
+		case keys["IPAM"] != nil:
+			var network Network
+			if err := json.Unmarshal(object, &network); err != nil {
+				return nil, fmt.Errorf("failed to parse network: %w", err)
+			}
+			res.Networks = append(res.Networks, &network)
+		case keys["Mountpoint"] != nil:
+			var volume Volume
+			if err := json.Unmarshal(object, &volume); err != nil {
+				return nil, fmt.Errorf("failed to parse volume: %w", err)
+			}
+			res.Volumes = append(res.Volumes, &volume)
+		}
+	}
+	return res, nil
+}
+
+// Image returns the image with the given ID or tag, or nil if it is not part of the output.
+func (r *Result) Image(ref string) *Image {
+	for _, image := range r.Images {
+		if image.ID == ref || strings.TrimPrefix(image.ID, "sha256:") == ref {
+			return image
+		}
+		for _, tag := range image.RepoTags {
+			if tag == ref {
+				return image
+			}
+		}
+	}
+	return nil
+}
+
+// Network returns the network with the given name or ID, or nil if it is not part of the output.
+func (r *Result) Network(ref string) *Network {
+	for _, network := range r.Networks {
+		if network.Name == ref || network.ID == ref {
+			return network
+		}
+	}
+	return nil
+}
+
+// Volume returns the volume with the given name, or nil if it is not part of the output.
+func (r *Result) Volume(name string) *Volume {
+	for _, volume := range r.Volumes {
+		if volume.Name == name {
+			return volume
+		}
+	}
+	return nil
+}
+
+// ContainerName returns the name of the container without the leading slash.
+func (c *Container) ContainerName() string {
+	return strings.TrimPrefix(c.Name, "/")
+}
+
+// ImageName returns the image the container was created from as it was given to docker run.
+func (c *Container) ImageName() string {
+	if c.Config.Image != "" {
+		return c.Config.Image
+	}
+	return c.Image
+}
@@ -0,0 +1,92 @@
+package inspect
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecode(t *testing.T) {
+	f, err := os.Open("testdata/inspect.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := Decode(f)
+	require.NoError(t, err)
+	require.Len(t, res.Containers, 2)
+	require.Len(t, res.Images, 1)
+	require.Equal(t, "web", res.Containers[0].ContainerName())
+	require.Equal(t, "nginx:1.25", res.Containers[0].ImageName())
+	require.Same(t, res.Images[0], res.Image(res.Containers[0].Image))
+	require.Same(t, res.Images[0], res.Image("nginx:1.25"))
+	require.Nil(t, res.Image(res.Containers[1].Image))
+
+	res, err = Decode(strings.NewReader(`{"Id": "abc", "Name": "/api", "State": {}, "Config": {"Image": "api"}}`))
+	require.NoError(t, err)
+	require.Len(t, res.Containers, 1)
+
+	_, err = Decode(strings.NewReader(" "))
+	require.ErrorContains(t, err, "empty docker inspect output")
+
+	_, err = Decode(strings.NewReader("not json"))
+	require.ErrorContains(t, err, "failed to parse docker inspect output")
+}
+
+func TestRunArgs(t *testing.T) {
+	f, err := os.Open("testdata/inspect.json")
+	require.NoError(t, err)
+	defer f.Close()
+
+	res, err := Decode(f)
+	require.NoError(t, err)
+
+	web := res.Containers[0]
+	require.Equal(t, []string{
+		"--name=web",
+		"--env=APP_ENV=production",
+		"--label=com.example.team=web",
+		"--publish=8080:80",
+		"--publish=127.0.0.1:8443:443",
+		"--expose=9000",
+		"--volume=/etc/app:/etc/app:ro",
+		"--volume=data:/var/lib/data",
+		"--tmpfs=/run:size=64m",
+		"--network=backend",
+		"--network-alias=proxy",
+		"--add-host=db.local:10.0.0.5",
+		"--restart=on-failure:3",
+		"--health-cmd=curl -f http://localhost/ || exit 1",
+		"--health-interval=30s",
+		"--health-timeout=5s",
+		"--health-retries=3",
+		"--cpus=0.5",
+		"--memory=512m",
+		"--ulimit=nofile=1024:2048",
+		"--cap-add=NET_ADMIN",
+		"--init",
+		"--log-driver=json-file",
+		"--log-opt=max-size=10m",
+		"nginx:1.25",
+	}, RunArgs(web, res.Image(web.Image)))
+
+	// without the image the command and environment of the image are kept
+	require.Equal(t, []string{
+		"--name=cache",
+		"--env=PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin",
+		"--restart=always",
+		"--entrypoint=docker-entrypoint.sh",
+		"redis:7",
+		"redis-server",
+		"--appendonly",
+		"yes",
+	}, RunArgs(res.Containers[1], nil))
+}
+
+func TestFormatBytes(t *testing.T) {
+	require.Equal(t, "2g", formatBytes(2<<30))
+	require.Equal(t, "1536m", formatBytes(1536<<20))
+	require.Equal(t, "100k", formatBytes(100<<10))
+	require.Equal(t, "1000", formatBytes(1000))
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// annotate adds comments to the rendered service naming the flag each attribute was converted from,
+// eg: "# from -p 8080:80", and a comment after the service listing the flags which were dropped.
+func (s *ServiceConfig) annotate(node *yaml.Node) {
+	var dropped []string
+	// items counts the flags appended to each sequence, to find the item added by a flag
+	items := make(map[string]int)
+
+	for _, f := range s.annotations {
+		if f.ComposeName == "" {
+			reason := ""
+			if !f.Known() {
+				reason = " (unknown flag)"
+			}
+			dropped = append(dropped, "  "+f.source()+reason)
+			continue
+		}
+		if f.ComposeName == serviceKey {
+			continue
+		}
+
+		path, isVar := strings.CutSuffix(f.ComposeName, ".$var")
+		key, value := pathEntry(node, strings.Split(path, "."))
+		if key == nil {
+			// the attribute was not rendered, eg: a boolean flag set to false
+			continue
+		}
+		comment := "from " + f.source()
+
+		switch {
+		case isVar && value.Kind == yaml.SequenceNode:
+			i := items[path]
+			items[path]++
+			if i < len(value.Content) {
+				annotateNode(nil, value.Content[i], comment)
+				continue
+			}
+		case isVar && value.Kind == yaml.MappingNode:
+			k, _, _ := strings.Cut(trimQuotes(f.Value), "=")
+			if itemKey, itemValue := mappingEntry(value, k); itemKey != nil {
+				annotateNode(itemKey, itemValue, comment)
+				continue
+			}
+		case !isVar:
+			annotateNode(key, value, comment)
+			continue
+		}
+		key.HeadComment = joinComment(key.HeadComment, comment)
+	}
+
+	if len(dropped) > 0 && len(node.Content) > 0 {
+		last := node.Content[len(node.Content)-2]
+		last.FootComment = joinComment(last.FootComment, "dropped flags:\n"+strings.Join(dropped, "\n"))
+	}
+}
+
+// annotateNode adds the comment at the end of a scalar value, or above its key or the item.
+func annotateNode(key, value *yaml.Node, comment string) {
+	switch {
+	case value.Kind == yaml.ScalarNode:
+		value.LineComment = comment
+	case key != nil:
+		key.HeadComment = joinComment(key.HeadComment, comment)
+	default:
+		value.HeadComment = joinComment(value.HeadComment, comment)
+	}
+}
+
+// pathEntry returns the key and value nodes at the attribute path of a service node.
+func pathEntry(node *yaml.Node, path []string) (*yaml.Node, *yaml.Node) {
+	var key *yaml.Node
+	for _, segment := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil, nil
+		}
+		if key, node = mappingEntry(node, segment); key == nil {
+			return nil, nil
+		}
+	}
+	return key, node
+}
+
+func joinComment(comment, line string) string {
+	if comment == "" {
+		return line
+	}
+	return comment + "\n" + line
+}
@@ -0,0 +1,73 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnnotate(t *testing.T) {
+	p, err := New(`docker run -d -t --rm=false -p 8080:80 --publish 443:443 -e A=1 --env "B=hello world" `+
+		`--mount type=bind,src=/a,dst=/b -v /c:/d --ulimit nofile=1024:2048 --expose 80 --memory 512m `+
+		`--init=false --gpus all --foo=bar nginx nginx -g "daemon off;"`, WithAnnotate(true), WithTarget(""))
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+	require.Equal(t, `services:
+    nginx:
+        tty: true # from -t
+        ports:
+            - 8080:80 # from -p 8080:80
+            - 443:443 # from --publish 443:443
+        environment:
+            A: 1 # from -e A=1
+            B: hello world # from --env 'B=hello world'
+        volumes:
+            # from --mount type=bind,src=/a,dst=/b
+            - type: bind
+              source: /a
+              target: /b
+            - /c:/d # from -v /c:/d
+        ulimits:
+            # from --ulimit nofile=1024:2048
+            nofile:
+                soft: 1024
+                hard: 2048
+        expose:
+            - 80 # from --expose 80
+        deploy:
+            resources:
+                limits:
+                    memory: 512m # from --memory 512m
+        image: nginx
+        command:
+            - nginx
+            - -g
+            - daemon off;
+        # dropped flags:
+        #   -d
+        #   --rm false
+        #   --gpus all
+        #   --foo bar (unknown flag)
+`, p.String())
+
+	t.Run("without annotate", func(t *testing.T) {
+		p, err := New("docker run -d -p 8080:80 nginx", WithTarget(""))
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, "services:\n    nginx:\n        ports:\n            - 8080:80\n        image: nginx\n", p.String())
+	})
+
+	t.Run("appended service", func(t *testing.T) {
+		p, err := AppendToYAML([]byte("services:\n  db:\n    image: postgres\n"), "docker run -p 8080:80 nginx", WithAnnotate(true))
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, `services:
+  db:
+    image: postgres
+  nginx:
+    ports:
+      - 8080:80 # from -p 8080:80
+    image: nginx
+`, p.String())
+	})
+}
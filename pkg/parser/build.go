@@ -0,0 +1,215 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// dockerBuild is the docker command building the image of a service.
+const dockerBuild = "docker build"
+
+var buildFlags = commandFlags{
+	command: dockerBuild,
+	bools: map[string]bool{
+		"compress":              true,
+		"disable-content-trust": true,
+		"force-rm":              true,
+		"load":                  true,
+		"no-cache":              true,
+		"pull":                  true,
+		"push":                  true,
+		"quiet":                 true,
+		"rm":                    true,
+		"squash":                true,
+	},
+	aliases: map[string]string{
+		"f": "file",
+		"q": "quiet",
+		"t": "tag",
+	},
+}
+
+// buildOnlyFlags only change the behaviour of the docker build command itself and are dropped.
+var buildOnlyFlags = map[string]bool{
+	"builder":               true,
+	"compress":              true,
+	"disable-content-trust": true,
+	"force-rm":              true,
+	"iidfile":               true,
+	"load":                  true,
+	"metadata-file":         true,
+	"progress":              true,
+	"push":                  true,
+	"quiet":                 true,
+	"rm":                    true,
+}
+
+// BuildConfig represents the build section of a service, how its image is built.
+type BuildConfig struct {
+	Context    string   `yaml:"context,omitempty"`
+	Dockerfile string   `yaml:"dockerfile,omitempty"`
+	Args       Mapping  `yaml:"args,omitempty"`
+	SSH        []string `yaml:"ssh,omitempty"`
+	CacheFrom  []string `yaml:"cache_from,omitempty"`
+	CacheTo    []string `yaml:"cache_to,omitempty"`
+	ExtraHosts []string `yaml:"extra_hosts,omitempty"`
+	Isolation  string   `yaml:"isolation,omitempty"`
+	Labels     Mapping  `yaml:"labels,omitempty"`
+	Network    string   `yaml:"network,omitempty"`
+	NoCache    bool     `yaml:"no_cache,omitempty"`
+	Platforms  []string `yaml:"platforms,omitempty"`
+	Pull       bool     `yaml:"pull,omitempty"`
+	ShmSize    string   `yaml:"shm_size,omitempty"`
+	Target     string   `yaml:"target,omitempty"`
+	// Tags are the images the build is tagged with besides the image of the service.
+	Tags []string `yaml:"tags,omitempty"`
+}
+
+// NewBuild parses a docker build command into the build section of a service.
+// All images tagged with -t are returned in Tags until the build is set on a service with SetBuild.
+// The Dockerfile given with -f, which docker build resolves against the working directory,
+// is made relative to the build context.
+func NewBuild(command string) (*BuildConfig, error) {
+	flags, context, err := buildFlags.parse(command)
+	if err != nil {
+		return nil, err
+	}
+	if context == "" {
+		return nil, errors.New("missing build context")
+	}
+	if context == "-" {
+		return nil, errors.New("reading the build context from stdin is not supported")
+	}
+
+	build := &BuildConfig{Context: context}
+	var dockerfile string
+	for _, f := range flags {
+		switch f.Key {
+		case "file":
+			dockerfile = f.Value
+		case "tag":
+			build.Tags = append(build.Tags, f.Value)
+		case "build-arg":
+			k, v, _ := strings.Cut(f.Value, "=")
+			build.Args.Set(k, v)
+		case "label":
+			k, v, _ := strings.Cut(f.Value, "=")
+			build.Labels.Set(k, v)
+		case "target":
+			build.Target = f.Value
+		case "cache-from":
+			build.CacheFrom = append(build.CacheFrom, f.Value)
+		case "cache-to":
+			build.CacheTo = append(build.CacheTo, f.Value)
+		case "platform":
+			build.Platforms = append(build.Platforms, strings.Split(f.Value, ",")...)
+		case "network":
+			build.Network = f.Value
+		case "no-cache":
+			build.NoCache = f.Value == "true"
+		case "pull":
+			build.Pull = f.Value == "true"
+		case "shm-size":
+			build.ShmSize = f.Value
+		case "add-host":
+			build.ExtraHosts = append(build.ExtraHosts, f.Value)
+		case "isolation":
+			build.Isolation = f.Value
+		case "ssh":
+			build.SSH = append(build.SSH, f.Value)
+		default:
+			if !buildOnlyFlags[f.Key] {
+				return nil, fmt.Errorf("%s flag %q is not supported in docker compose", dockerBuild, f.Key)
+			}
+		}
+	}
+
+	if dockerfile != "" {
+		if build.Dockerfile, err = contextPath(context, dockerfile); err != nil {
+			return nil, err
+		}
+	}
+	return build, nil
+}
+
+// contextPath returns the path of the Dockerfile relative to a local build context.
+// The path is kept for remote contexts.
+func contextPath(context, dockerfile string) (string, error) {
+	if dockerfile == "-" {
+		return "", errors.New("reading the Dockerfile from stdin is not supported")
+	}
+	if remoteContext(context) || filepath.IsAbs(dockerfile) {
+		return dockerfile, nil
+	}
+	rel, err := filepath.Rel(context, dockerfile)
+	if err != nil {
+		return dockerfile, nil
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// remoteContext reports whether the build context is a git repository or a tarball URL.
+func remoteContext(context string) bool {
+	return strings.Contains(context, "://") || strings.HasPrefix(context, "git@")
+}
+
+// Rebase rewrites a local build context relative to dir, the directory docker build runs in,
+// to be relative to composeDir, the directory of the docker compose file.
+// The Dockerfile is relative to the context and is kept.
+func (b *BuildConfig) Rebase(dir, composeDir string) error {
+	if remoteContext(b.Context) || filepath.IsAbs(b.Context) {
+		return nil
+	}
+	context, err := filepath.Abs(filepath.Join(dir, b.Context))
+	if err != nil {
+		return err
+	}
+	composeDir, err = filepath.Abs(composeDir)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(composeDir, context)
+	if err != nil {
+		return err
+	}
+	b.Context = filepath.ToSlash(rel)
+	return nil
+}
+
+// Tagged reports whether the build tags the image, eg: myapp for a build tagged with myapp:latest.
+func (b *BuildConfig) Tagged(image string) bool {
+	for _, tag := range b.Tags {
+		if normalizeImage(tag) == normalizeImage(image) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetBuild builds the image of the service with a copy of the build.
+// The tags other than the image of the service are kept as additional tags.
+// The build is rendered at the position of the image.
+func (s *ServiceConfig) SetBuild(build *BuildConfig) {
+	b := *build
+	b.Tags = nil
+	for _, tag := range build.Tags {
+		if normalizeImage(tag) != normalizeImage(s.Image) {
+			b.Tags = append(b.Tags, tag)
+		}
+	}
+	if pos, ok := s.order["image"]; ok {
+		s.order["build"] = pos
+	}
+	s.Build = &b
+}
+
+// normalizeImage adds the implicit latest tag to an image reference without a tag or digest.
+func normalizeImage(image string) string {
+	if strings.Contains(image, "@") || strings.Contains(path.Base(image), ":") {
+		return image
+	}
+	return image + ":latest"
+}
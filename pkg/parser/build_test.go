@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewBuild(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    *BuildConfig
+		wantErr string
+	}{
+		{
+			name:    "context only",
+			command: "docker build .",
+			want:    &BuildConfig{Context: "."},
+		},
+		{
+			name: "options",
+			command: "docker build -t myapp --build-arg X=1 --build-arg TOKEN -f docker/Dockerfile --target prod " +
+				"--label org=acme --cache-from myapp:cache --platform linux/amd64,linux/arm64 --no-cache -q --progress=plain .",
+			want: &BuildConfig{
+				Context:    ".",
+				Dockerfile: "docker/Dockerfile",
+				Args:       Mapping{{Key: "X", Value: "1"}, {Key: "TOKEN"}},
+				CacheFrom:  []string{"myapp:cache"},
+				Labels:     Mapping{{Key: "org", Value: "acme"}},
+				NoCache:    true,
+				Platforms:  []string{"linux/amd64", "linux/arm64"},
+				Target:     "prod",
+				Tags:       []string{"myapp"},
+			},
+		},
+		{
+			name:    "dockerfile relative to the context",
+			command: "docker build -f app/docker/Dockerfile app",
+			want:    &BuildConfig{Context: "app", Dockerfile: "docker/Dockerfile"},
+		},
+		{
+			name:    "remote context",
+			command: "docker build -f docker/Dockerfile https://github.com/example/app.git#main",
+			want:    &BuildConfig{Context: "https://github.com/example/app.git#main", Dockerfile: "docker/Dockerfile"},
+		},
+		{
+			name:    "missing context",
+			command: "docker build -t myapp",
+			wantErr: "missing build context",
+		},
+		{
+			name:    "context from stdin",
+			command: "docker build -t myapp -",
+			wantErr: "reading the build context from stdin is not supported",
+		},
+		{
+			name:    "unsupported flag",
+			command: "docker build --squash .",
+			wantErr: `docker build flag "squash" is not supported in docker compose`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewBuild(tt.command)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestServiceConfigSetBuild(t *testing.T) {
+	build, err := NewBuild("docker build -t myapp:latest -t registry.example.com/myapp:1.0 --target prod .")
+	require.NoError(t, err)
+	require.True(t, build.Tagged("myapp"))
+	require.True(t, build.Tagged("registry.example.com/myapp:1.0"))
+	require.False(t, build.Tagged("myapp:1.0"))
+
+	p, err := New("docker run -p 8080:80 myapp serve", WithTarget(""))
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+	p.Service().SetBuild(build)
+	_, err = p.Render()
+	require.NoError(t, err)
+	require.Equal(t, `services:
+    myapp:
+        ports:
+            - 8080:80
+        build:
+            context: .
+            target: prod
+            tags:
+                - registry.example.com/myapp:1.0
+        image: myapp
+        command:
+            - serve
+`, p.String())
+	require.Len(t, build.Tags, 2, "the build passed to SetBuild is not modified")
+}
+
+func TestBuildConfigRebase(t *testing.T) {
+	tests := []struct {
+		name       string
+		context    string
+		dir        string
+		composeDir string
+		want       string
+	}{
+		{name: "script in a sub directory", context: ".", dir: "docs", composeDir: ".", want: "docs"},
+		{name: "compose file in a sub directory", context: "app", dir: ".", composeDir: "deploy", want: "../app"},
+		{name: "same directory", context: "..", dir: "docs", composeDir: "docs", want: ".."},
+		{name: "absolute context", context: "/src/app", dir: "docs", composeDir: "deploy", want: "/src/app"},
+		{name: "remote context", context: "https://github.com/example/app.git#main", dir: "docs", composeDir: ".", want: "https://github.com/example/app.git#main"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			build := &BuildConfig{Context: tt.context, Dockerfile: "docker/Dockerfile"}
+			require.NoError(t, build.Rebase(tt.dir, tt.composeDir))
+			require.Equal(t, &BuildConfig{Context: tt.want, Dockerfile: "docker/Dockerfile"}, build)
+		})
+	}
+}
@@ -3,4 +3,8 @@ package parser
 const (
 	composeVersion     = "3.8"
 	defaultServiceName = "container1"
+	defaultIndent      = 4
+	// minIndent and maxIndent bound the indentation the yaml encoder supports.
+	minIndent = 2
+	maxIndent = 9
 )
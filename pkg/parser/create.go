@@ -0,0 +1,221 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Docker commands creating the top-level networks and volumes of a docker compose file.
+const (
+	dockerNetworkCreate = "docker network create"
+	dockerVolumeCreate  = "docker volume create"
+)
+
+// commandFlags describes the flags of a docker command converted into a part of a docker compose file.
+type commandFlags struct {
+	command string
+	// bools are the boolean flags and aliases the shorthands of the flags.
+	bools   map[string]bool
+	aliases map[string]string
+}
+
+var networkCreateFlags = commandFlags{
+	command: dockerNetworkCreate,
+	bools: map[string]bool{
+		"attachable":  true,
+		"config-only": true,
+		"ingress":     true,
+		"internal":    true,
+		"ipv6":        true,
+	},
+	aliases: map[string]string{
+		"d": "driver",
+		"o": "opt",
+	},
+}
+
+var volumeCreateFlags = commandFlags{
+	command: dockerVolumeCreate,
+	aliases: map[string]string{
+		"d": "driver",
+		"o": "opt",
+	},
+}
+
+// NewNetwork parses a docker network create command into a top-level network of a docker compose file.
+// The network is named explicitly, otherwise docker compose would prefix it with the project name.
+// The addresses given with --ip-range, --gateway and --aux-address are added to the pool of the
+// --subnet containing them.
+func NewNetwork(command string) (*NetworkConfig, error) {
+	flags, name, err := networkCreateFlags.parse(command)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, errors.New("missing network name")
+	}
+
+	network := &NetworkConfig{Name: name, NetworkName: name}
+	var ipamOpts Mapping
+	var ipamDriver string
+	var pools []*IPAMPool
+	var addresses []KeyValue
+	for _, f := range flags {
+		switch f.Key {
+		case "driver":
+			network.Driver = f.Value
+		case "opt":
+			k, v, _ := strings.Cut(f.Value, "=")
+			network.DriverOpts.Set(k, v)
+		case "label":
+			k, v, _ := strings.Cut(f.Value, "=")
+			network.Labels.Set(k, v)
+		case "attachable":
+			network.Attachable = f.Value == "true"
+		case "internal":
+			network.Internal = f.Value == "true"
+		case "ipv6":
+			network.EnableIPv6 = f.Value == "true"
+		case "ipam-driver":
+			ipamDriver = f.Value
+		case "ipam-opt":
+			k, v, _ := strings.Cut(f.Value, "=")
+			ipamOpts.Set(k, v)
+		case "subnet":
+			pools = append(pools, &IPAMPool{Subnet: f.Value})
+		case "ip-range", "gateway", "aux-address":
+			addresses = append(addresses, f)
+		case "scope":
+			// the scope follows from the driver in docker compose
+		case "config-only", "config-from", "ingress":
+			return nil, fmt.Errorf("%s flag %q is not supported in docker compose", dockerNetworkCreate, f.Key)
+		default:
+			return nil, fmt.Errorf("unknown %s flag %q", dockerNetworkCreate, f.Key)
+		}
+	}
+
+	for _, f := range addresses {
+		value := f.Value
+		var auxName string
+		if f.Key == "aux-address" {
+			auxName, value, _ = strings.Cut(value, "=")
+		}
+		pool, err := subnetPool(pools, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", f.Key, f.Value, err)
+		}
+		switch f.Key {
+		case "ip-range":
+			pool.IPRange = value
+		case "gateway":
+			pool.Gateway = value
+		default:
+			pool.AuxAddresses.Set(auxName, value)
+		}
+	}
+
+	if ipamDriver != "" || len(ipamOpts) > 0 || len(pools) > 0 {
+		network.Ipam = &IPAMConfig{Driver: ipamDriver, Config: pools, Options: ipamOpts}
+	}
+	return network, nil
+}
+
+// subnetPool returns the pool whose subnet contains the address or CIDR range.
+func subnetPool(pools []*IPAMPool, address string) (*IPAMPool, error) {
+	ip, _, err := net.ParseCIDR(address)
+	if err != nil {
+		if ip = net.ParseIP(address); ip == nil {
+			return nil, errors.New("not an IP address")
+		}
+	}
+	for _, pool := range pools {
+		if _, subnet, err := net.ParseCIDR(pool.Subnet); err == nil && subnet.Contains(ip) {
+			return pool, nil
+		}
+	}
+	return nil, errors.New("no matching subnet")
+}
+
+// NewVolume parses a docker volume create command into a top-level volume of a docker compose file.
+// The volume is named explicitly, otherwise docker compose would prefix it with the project name.
+func NewVolume(command string) (*VolumeConfig, error) {
+	flags, name, err := volumeCreateFlags.parse(command)
+	if err != nil {
+		return nil, err
+	}
+
+	volume := &VolumeConfig{Name: name, VolumeName: name}
+	for _, f := range flags {
+		switch f.Key {
+		case "driver":
+			volume.Driver = f.Value
+		case "opt":
+			k, v, _ := strings.Cut(f.Value, "=")
+			volume.DriverOpts.Set(k, v)
+		case "label":
+			k, v, _ := strings.Cut(f.Value, "=")
+			volume.Labels.Set(k, v)
+		case "name":
+			volume.Name = f.Value
+			volume.VolumeName = f.Value
+		default:
+			return nil, fmt.Errorf("%s flag %q is not supported in docker compose", dockerVolumeCreate, f.Key)
+		}
+	}
+	if volume.Name == "" {
+		return nil, errors.New("missing volume name")
+	}
+	return volume, nil
+}
+
+// parse returns the flags of the command in order, with shorthands resolved to their long name,
+// and the argument of the command, eg: the name of the network.
+func (c commandFlags) parse(command string) ([]KeyValue, string, error) {
+	s, ok := strings.CutPrefix(strings.TrimSpace(command), c.command)
+	if !ok {
+		return nil, "", fmt.Errorf("not a %s command", c.command)
+	}
+	args, err := parseArgs(s)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to parse %s command: %w", c.command, err)
+	}
+
+	var flags []KeyValue
+	var names []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "\\" {
+			continue
+		}
+		if len(arg) < 2 || arg[0] != '-' {
+			names = append(names, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if long, ok := c.aliases[name]; ok {
+			name = long
+		}
+		switch {
+		case c.bools[name] && !hasValue:
+			value = "true"
+		case !hasValue:
+			if i+1 == len(args) {
+				return nil, "", fmt.Errorf("%s flag %q is missing an argument", c.command, name)
+			}
+			i++
+			value = args[i]
+		}
+		flags = append(flags, KeyValue{Key: name, Value: trimQuotes(value)})
+	}
+
+	switch len(names) {
+	case 0:
+		return flags, "", nil
+	case 1:
+		return flags, names[0], nil
+	}
+	return nil, "", fmt.Errorf("%s accepts a single argument, got %s", c.command, strings.Join(names, " "))
+}
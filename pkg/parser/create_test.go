@@ -0,0 +1,123 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewNetwork(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    *NetworkConfig
+		wantErr string
+	}{
+		{
+			name:    "name only",
+			command: "docker network create appnet",
+			want:    &NetworkConfig{Name: "appnet", NetworkName: "appnet"},
+		},
+		{
+			name: "ipam",
+			command: "docker network create -d bridge --subnet 10.0.0.0/24 --subnet=10.1.0.0/16 --gateway 10.1.0.1 " +
+				"--ip-range 10.0.0.128/25 --aux-address router=10.0.0.2 --ipam-opt foo=bar --internal --attachable " +
+				"--label team=core -o com.docker.network.bridge.name=app0 appnet",
+			want: &NetworkConfig{
+				Name:        "appnet",
+				NetworkName: "appnet",
+				Driver:      "bridge",
+				DriverOpts:  Mapping{{Key: "com.docker.network.bridge.name", Value: "app0"}},
+				Attachable:  true,
+				Internal:    true,
+				Ipam: &IPAMConfig{
+					Config: []*IPAMPool{
+						{Subnet: "10.0.0.0/24", IPRange: "10.0.0.128/25", AuxAddresses: Mapping{{Key: "router", Value: "10.0.0.2"}}},
+						{Subnet: "10.1.0.0/16", Gateway: "10.1.0.1"},
+					},
+					Options: Mapping{{Key: "foo", Value: "bar"}},
+				},
+				Labels: Mapping{{Key: "team", Value: "core"}},
+			},
+		},
+		{
+			name:    "gateway outside the subnets",
+			command: "docker network create --subnet 10.0.0.0/24 --gateway 10.1.0.1 appnet",
+			wantErr: `invalid gateway "10.1.0.1": no matching subnet`,
+		},
+		{
+			name:    "missing name",
+			command: "docker network create --internal",
+			wantErr: "missing network name",
+		},
+		{
+			name:    "unsupported flag",
+			command: "docker network create --ingress -d overlay ingress",
+			wantErr: `docker network create flag "ingress" is not supported in docker compose`,
+		},
+		{
+			name:    "unknown flag",
+			command: "docker network create --foo bar appnet",
+			wantErr: `unknown docker network create flag "foo"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewNetwork(tt.command)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNewVolume(t *testing.T) {
+	got, err := NewVolume(`docker volume create --driver local --opt type=nfs -o "o=addr=10.0.0.5,rw" --label backup=daily data`)
+	require.NoError(t, err)
+	require.Equal(t, &VolumeConfig{
+		Name:       "data",
+		VolumeName: "data",
+		Driver:     "local",
+		DriverOpts: Mapping{
+			{Key: "type", Value: "nfs"},
+			{Key: "o", Value: "addr=10.0.0.5,rw"},
+		},
+		Labels: Mapping{{Key: "backup", Value: "daily"}},
+	}, got)
+
+	got, err = NewVolume("docker volume create --name cache")
+	require.NoError(t, err)
+	require.Equal(t, &VolumeConfig{Name: "cache", VolumeName: "cache"}, got)
+
+	_, err = NewVolume("docker volume create -d local")
+	require.EqualError(t, err, "missing volume name")
+
+	_, err = NewVolume("docker volume create a b")
+	require.EqualError(t, err, "docker volume create accepts a single argument, got a b")
+}
+
+func TestProjectConnectNetworks(t *testing.T) {
+	p, err := New("docker run --network appnet --ip 10.0.0.5 --network-alias web nginx", WithTarget(""))
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+
+	p.Project().Networks = append(p.Project().Networks, &NetworkConfig{Name: "appnet", Internal: true})
+	p.Project().ConnectNetworks()
+	_, err = p.Render()
+	require.NoError(t, err)
+	require.Equal(t, `services:
+    nginx:
+        networks:
+            appnet:
+                ipv4_address: 10.0.0.5
+                aliases:
+                    - web
+        image: nginx
+networks:
+    appnet:
+        internal: true
+`, p.String())
+}
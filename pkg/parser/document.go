@@ -0,0 +1,233 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// existingDocument is a docker compose file loaded by AppendToYAML or UpdateYAML.
+// Changes are spliced into the original text so that everything outside the
+// added or updated service keeps its formatting, comments, anchors and key order.
+type existingDocument struct {
+	source []byte
+	root   *yaml.Node
+
+	servicesKey *yaml.Node
+	services    *yaml.Node
+}
+
+// encodeNode encodes a yaml node using the given indentation.
+func encodeNode(node *yaml.Node, indent int) ([]byte, error) {
+	clearMergeTags(node)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(indent)
+	if err := enc.Encode(node); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// clearMergeTags removes the !!merge tag the decoder sets on merge keys,
+// otherwise the encoder writes it explicitly as `!!merge <<: *anchor`.
+func clearMergeTags(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!merge" && node.Value == "<<" {
+		node.Tag = ""
+	}
+	for _, n := range node.Content {
+		clearMergeTags(n)
+	}
+}
+
+// findAnchor returns the node with the given anchor or nil if there is no such node.
+func findAnchor(node *yaml.Node, anchor string) *yaml.Node {
+	if node.Anchor == anchor {
+		return node
+	}
+	for _, n := range node.Content {
+		if found := findAnchor(n, anchor); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// extend makes the service mapping extend the mapping with the given anchor using a merge key.
+// Attributes of the service which are equal to the ones of the anchor are removed.
+func (d *existingDocument) extend(service *yaml.Node, anchor string) (*yaml.Node, error) {
+	anchorNode := findAnchor(d.root, anchor)
+	if anchorNode == nil {
+		return nil, fmt.Errorf("anchor %q not found in docker compose file", anchor)
+	}
+	if anchorNode.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("anchor %q is not a mapping", anchor)
+	}
+	if anchorNode.Line > blockEnd(splitLines(d.source), d.servicesKey.Line, d.servicesKey.Column) {
+		return nil, fmt.Errorf("anchor %q must be defined before the end of the services", anchor)
+	}
+
+	extended := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			scalarNode("<<"),
+			{
+				Kind:  yaml.AliasNode,
+				Value: anchor,
+				Alias: anchorNode,
+			},
+		},
+	}
+	for i := 0; i+1 < len(service.Content); i += 2 {
+		key, value := service.Content[i], service.Content[i+1]
+		if inherited := mappingValue(anchorNode, key.Value); inherited != nil && nodeEqual(inherited, value) {
+			continue
+		}
+		extended.Content = append(extended.Content, key, value)
+	}
+	return extended, nil
+}
+
+// indent detects the indentation of the file from the services block.
+// It returns 0 if the indentation cannot be detected.
+func (d *existingDocument) indent() int {
+	if d.services.Kind != yaml.MappingNode || len(d.services.Content) < 2 {
+		return 0
+	}
+	key, value := d.services.Content[0], d.services.Content[1]
+	if value.Kind == yaml.MappingNode && value.Style&yaml.FlowStyle == 0 && len(value.Content) > 0 && value.Content[0].Line > key.Line {
+		return value.Content[0].Column - key.Column
+	}
+	if key.Line > d.servicesKey.Line {
+		return key.Column - d.servicesKey.Column
+	}
+	return 0
+}
+
+// spliceable reports whether the services block is written in block style and
+// can be edited by text splicing.
+func (d *existingDocument) spliceable(existingServices int) bool {
+	return d.services.Kind == yaml.MappingNode &&
+		d.services.Style&yaml.FlowStyle == 0 &&
+		existingServices > 0 &&
+		d.services.Content[0].Line > d.servicesKey.Line
+}
+
+// insertService renders a service and inserts it at the end of the services block.
+func (d *existingDocument) insertService(key string, value *yaml.Node, indent int) ([]byte, error) {
+	lines := splitLines(d.source)
+	end := blockEnd(lines, d.servicesKey.Line, d.servicesKey.Column)
+	base := d.services.Content[0].Column - 1
+
+	b, err := encodeBlock(key, value, indent, base)
+	if err != nil {
+		return nil, err
+	}
+
+	return joinLines(lines[:end], []string{d.lineBreaks(b)}, lines[end:]), nil
+}
+
+// replaceService renders a service and replaces the existing block of the service key.
+func (d *existingDocument) replaceService(key, value *yaml.Node, indent int) ([]byte, error) {
+	lines := splitLines(d.source)
+	start := key.Line - 1
+	end := blockEnd(lines, key.Line, key.Column)
+
+	// the head comment is kept as it is written above the replaced lines
+	title := *key
+	title.HeadComment = ""
+
+	b, err := encodeBlockNode(&title, value, indent, key.Column-1)
+	if err != nil {
+		return nil, err
+	}
+
+	return joinLines(lines[:start], []string{d.lineBreaks(b)}, lines[end:]), nil
+}
+
+// lineBreaks converts the line breaks of encoded yaml to the ones used by the file.
+func (d *existingDocument) lineBreaks(b []byte) string {
+	if bytes.Contains(d.source, []byte("\r\n")) {
+		return strings.ReplaceAll(string(b), "\n", "\r\n")
+	}
+	return string(b)
+}
+
+// encodeBlock encodes a single key value pair indented by base spaces.
+func encodeBlock(key string, value *yaml.Node, indent, base int) ([]byte, error) {
+	return encodeBlockNode(&yaml.Node{Kind: yaml.ScalarNode, Value: key}, value, indent, base)
+}
+
+func encodeBlockNode(key, value *yaml.Node, indent, base int) ([]byte, error) {
+	b, err := encodeNode(&yaml.Node{
+		Kind:    yaml.MappingNode,
+		Content: []*yaml.Node{key, value},
+	}, indent)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.Repeat(" ", base)
+	var buf bytes.Buffer
+	for _, line := range splitLines(b) {
+		if strings.TrimSpace(line) != "" {
+			buf.WriteString(prefix)
+		}
+		buf.WriteString(line)
+	}
+	return buf.Bytes(), nil
+}
+
+// blockEnd returns the index of the line after the block of the key at the given line and column.
+// The block holds the lines indented deeper than the key. Blank lines and comments
+// which are not indented deeper at the end of the block are not part of it.
+func blockEnd(lines []string, line, column int) int {
+	end := line
+	for i := line; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" {
+			continue
+		}
+		indentation := len(lines[i]) - len(strings.TrimLeft(lines[i], " \t"))
+		if indentation < column {
+			if strings.HasPrefix(trimmed, "#") {
+				continue
+			}
+			break
+		}
+		end = i + 1
+	}
+	return end
+}
+
+// splitLines splits b into lines keeping the line endings.
+// The last line always ends with a line break.
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if last := lines[len(lines)-1]; !strings.HasSuffix(last, "\n") {
+		lines[len(lines)-1] = last + "\n"
+	}
+	return lines
+}
+
+func joinLines(parts ...[]string) []byte {
+	var buf bytes.Buffer
+	for _, lines := range parts {
+		for _, line := range lines {
+			buf.WriteString(line)
+		}
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,230 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAppendToYAMLPreservesFormatting(t *testing.T) {
+	tests := []struct {
+		name    string
+		compose string
+		command string
+		opts    []Option
+		want    string
+	}{
+		{
+			name: "comments, blank lines and trailing top-level keys are kept",
+			compose: `version: "3.8"
+
+services:
+  # the api service
+  api:
+    image: api   # pinned
+    ports: ["80:80"]
+    # trailing comment in api
+
+  db:
+    image: "postgres"
+
+# volumes used by the services
+volumes:
+  data: {}
+`,
+			command: "docker run -p 8080:80 nginx",
+			want: `version: "3.8"
+
+services:
+  # the api service
+  api:
+    image: api   # pinned
+    ports: ["80:80"]
+    # trailing comment in api
+
+  db:
+    image: "postgres"
+  nginx:
+    ports:
+      - 8080:80
+    image: nginx
+
+# volumes used by the services
+volumes:
+  data: {}
+`,
+		},
+		{
+			name: "indentation is detected from the services",
+			compose: `services:
+    db:
+        image: postgres`,
+			command: "docker run -e FOO=bar nginx",
+			want: `services:
+    db:
+        image: postgres
+    nginx:
+        environment:
+            FOO: bar
+        image: nginx
+`,
+		},
+		{
+			name: "indent option overrides the detected indentation",
+			compose: `services:
+  db:
+    image: postgres
+`,
+			command: "docker run -e FOO=bar nginx",
+			opts:    []Option{WithIndent(4)},
+			want: `services:
+  db:
+    image: postgres
+  nginx:
+      environment:
+          FOO: bar
+      image: nginx
+`,
+		},
+		{
+			name:    "empty services are encoded again",
+			compose: "services:\nvolumes:\n  data:\n",
+			command: "docker run nginx",
+			want: `services:
+    nginx:
+        image: nginx
+volumes:
+    data:
+`,
+		},
+		{
+			name:    "windows line breaks are kept",
+			compose: "services:\r\n  db:\r\n    image: postgres\r\n",
+			command: "docker run nginx",
+			want:    "services:\r\n  db:\r\n    image: postgres\r\n  nginx:\r\n    image: nginx\r\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := AppendToYAML([]byte(tt.compose), tt.command, tt.opts...)
+			require.NoError(t, err)
+			require.NoError(t, p.Parse())
+			require.Equal(t, tt.want, p.String())
+		})
+	}
+}
+
+func TestUpdateYAMLPreservesFormatting(t *testing.T) {
+	compose := `services:
+  # the api service
+  api:
+    image: api # pinned
+    ports:
+      - 80:80
+    # trailing comment in api
+
+  db:
+    image: postgres
+`
+	p, err := UpdateYAML([]byte(compose), "api", "docker run -p 9000:9000 -e FOO=1")
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+	require.Equal(t, `services:
+  # the api service
+  api:
+    image: api # pinned
+    ports:
+      - 80:80
+      - 9000:9000
+    environment:
+      FOO: 1
+    # trailing comment in api
+
+  db:
+    image: postgres
+`, p.String())
+}
+
+func TestAnchorsAndExtensionFields(t *testing.T) {
+	compose := `version: "3.8"
+
+x-logging: &logging
+  driver: json-file
+  options: {max-size: 10m}
+
+x-common: &common
+  restart: always
+  logging: *logging
+  environment: &env
+    TZ: UTC
+
+services:
+  api:
+    <<: *common
+    image: api
+    environment:
+      <<: *env
+      DEBUG: "1"
+`
+
+	t.Run("append keeps anchors, aliases and extension fields", func(t *testing.T) {
+		p, err := AppendToYAML([]byte(compose), "docker run --restart always nginx")
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, compose+`  nginx:
+    restart: always
+    image: nginx
+`, p.String())
+	})
+
+	t.Run("append extending an anchor", func(t *testing.T) {
+		p, err := AppendToYAML([]byte(compose), "docker run --restart always -p 80:80 nginx", WithExtendsAnchor("common"))
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, compose+`  nginx:
+    <<: *common
+    ports:
+      - 80:80
+    image: nginx
+`, p.String())
+	})
+
+	t.Run("update keeps merge keys", func(t *testing.T) {
+		p, err := UpdateYAML([]byte(compose), "api", "docker run -e DEBUG=0")
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, `version: "3.8"
+
+x-logging: &logging
+  driver: json-file
+  options: {max-size: 10m}
+
+x-common: &common
+  restart: always
+  logging: *logging
+  environment: &env
+    TZ: UTC
+
+services:
+  api:
+    <<: *common
+    image: api
+    environment:
+      <<: *env
+      DEBUG: "0"
+`, p.String())
+	})
+
+	t.Run("unknown anchor", func(t *testing.T) {
+		p, err := AppendToYAML([]byte(compose), "docker run nginx", WithExtendsAnchor("defaults"))
+		require.NoError(t, err)
+		require.ErrorContains(t, p.Parse(), `anchor "defaults" not found`)
+	})
+
+	t.Run("anchor without an existing file", func(t *testing.T) {
+		p, err := New("docker run nginx", WithExtendsAnchor("common"))
+		require.NoError(t, err)
+		require.ErrorContains(t, p.Parse(), `anchor "common" not found`)
+	})
+}
@@ -17,12 +17,13 @@ const (
 	DurationType
 	FileType
 	UlimitType
+	PortType
 )
 
 // YamlKind returns the yaml.Kind for the flag type.
 func (f FlagType) YamlKind() yaml.Kind {
 	switch f {
-	case ArrayType, FileType:
+	case ArrayType, FileType, PortType:
 		return yaml.SequenceNode
 	case BoolType, Float64Type, IntType, StringType, DurationType:
 		return yaml.ScalarNode
@@ -37,6 +38,33 @@ func (f FlagType) IsZero() bool {
 	return f == 0
 }
 
+var flagTypeNames = map[FlagType]string{
+	ArrayType:    "array",
+	BoolType:     "bool",
+	Float64Type:  "float",
+	IntType:      "int",
+	StringType:   "string",
+	MapType:      "map",
+	MountType:    "mount",
+	DurationType: "duration",
+	FileType:     "file",
+	UlimitType:   "ulimit",
+	PortType:     "port",
+}
+
+// String returns the name of the flag type, eg: array.
+func (f FlagType) String() string {
+	if name, ok := flagTypeNames[f]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// MarshalText encodes the flag type as its name.
+func (f FlagType) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
 // DockerFlag represents a docker run flag.
 type DockerFlag struct {
 	Type FlagType
@@ -46,11 +74,12 @@ type DockerFlag struct {
 	Reference string
 	// Alias is the alias of the flag in the docker run command.
 	Alias string
+	// Todo marks a flag without a ComposeName which could be converted in the future.
+	Todo bool
 }
 
 type variables struct {
-	vars        map[string]DockerFlag
-	specialVars map[string]FlagType
+	vars map[string]DockerFlag
 }
 
 // Get returns the docker flag for the variable.
@@ -65,19 +94,12 @@ func (v *variables) Get(s string) *DockerFlag {
 	return nil
 }
 
-// GetType returns the type of the variable including the special variables.
-// It first checks if the variable is a special variable, if not it checks if it is a variable.
-func (v *variables) GetType(s string) FlagType {
-	ftype := v.GetSpecialVarType(s)
-	if ftype.IsZero() {
-		ftype = v.GetVarType(s)
+// Name returns the name of the flag the variable refers to, resolving shorthands and aliases.
+func (v *variables) Name(s string) string {
+	if dockerFlag, ok := v.vars[s]; ok && dockerFlag.Reference != "" {
+		return dockerFlag.Reference
 	}
-	return ftype
-}
-
-// GetSpecialVarType returns the type of the special variable.
-func (v *variables) GetSpecialVarType(s string) FlagType {
-	return v.specialVars[s]
+	return s
 }
 
 // GetVarType returns the type of the variable excluding the special variables.
@@ -95,32 +117,6 @@ func (v *variables) GetVarType(s string) FlagType {
 func newVariables() *variables {
 	vars := &variables{}
 
-	// specialComposeTypes are top-level or parent keys that have special handling in the compose file.
-	// "^" indicates top level
-	// "$" indicates that it can be replaced
-	vars.specialVars = map[string]FlagType{
-		"annotations":  MapType,
-		"^services":    MapType,
-		"environment":  MapType,
-		"$service":     StringType,
-		"^volumes":     MapType,
-		"volumes":      ArrayType,
-		"logging":      MapType,
-		"options":      MapType, // logging.options
-		"healthcheck":  MapType,
-		"^networks":    MapType,
-		"networks":     MapType,
-		"default":      MapType, // networks.default
-		"ports":        ArrayType,
-		"blkio_config": MapType,
-		"storage_opt":  MapType,
-		"deploy":       MapType,
-		"resources":    MapType, // deploy.resources
-		"limits":       MapType, // deploy.limits or deploy.resources.limits
-		"reservations": MapType, // deploy.reservations
-		"sysctls":      MapType,
-	}
-
 	// map docker run flags to docker compose file flags
 	// Defined according to the specification here: https://github.com/compose-spec/compose-spec/blob/master/spec.md
 	vars.vars = map[string]DockerFlag{
@@ -274,11 +270,12 @@ func newVariables() *variables {
 		},
 		"expose": {
 			Type:        ArrayType,
-			ComposeName: "^services.$service.expose",
+			ComposeName: "^services.$service.expose.$var",
 		},
 		"gpus": { // TODO: to be supported
 			Type: StringType,
 			//ComposeName: "^services.$service.deploy.resources.reservations.gpus",
+			Todo: true,
 		},
 		"group-add": { // TODO: not supported in docker compose v3
 			Type:        StringType,
@@ -343,18 +340,19 @@ func newVariables() *variables {
 		"kernel-memory": { // TODO: to be supported
 			Type: StringType,
 			//ComposeName: "^services.$service.deploy.resources.reservations.memory",
+			Todo: true,
 		},
 		"l": {
 			Reference: "label",
 		},
 		"label": {
-			Type:        ArrayType,
+			Type:        MapType,
 			ComposeName: "^services.$service.labels.$var",
 			Alias:       "l",
 		},
-		"labels-file": {
+		"label-file": {
 			Type:        FileType,
-			ComposeName: "^services.$service.labels",
+			ComposeName: "^services.$service.label_file.$var",
 		},
 		"link": {
 			Type:        ArrayType,
@@ -450,6 +448,7 @@ func newVariables() *variables {
 		"publish-all": { // TODO: not sure how to handle this
 			Type: BoolType,
 			//ComposeName: "^services.$service.ports",
+			Todo: true,
 		},
 		"read-only": {
 			Type:        BoolType,
@@ -537,6 +536,7 @@ func newVariables() *variables {
 		"volume-driver": { // TODO: figure out how to support this
 			Type:        StringType,
 			ComposeName: "",
+			Todo:        true,
 		},
 		"volumes-from": {
 			Type:        ArrayType,
@@ -0,0 +1,169 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+// Flag is a docker run flag parsed from the command.
+type Flag struct {
+	// Name is the long name of the flag. Shorthands like -p are resolved to their long name.
+	Name string
+	// Alias is the shorthand or alias the flag was given with, eg: p for -p.
+	// It is empty when the flag was given with its long name.
+	Alias string
+	Value string
+	// Type is the type of the flag. It is zero for unknown flags.
+	Type FlagType
+	// ComposeName is the docker compose path the flag is converted into.
+	// It is empty for unknown flags and for flags which are not supported in docker compose.
+	ComposeName string
+}
+
+// Known reports whether the flag is a docker run flag.
+func (f Flag) Known() bool {
+	return !f.Type.IsZero()
+}
+
+// Arg returns the flag in the --name=value form, or -n=value for unknown one letter flags.
+// Boolean flags which are set are returned without a value.
+func (f Flag) Arg() string {
+	if f.Type == BoolType && f.Value == "true" {
+		return FlagArg(f.Name)
+	}
+	return FlagArg(f.Name) + "=" + f.Value
+}
+
+// source returns the flag as given in the docker command, eg: -p 8080:80.
+func (f Flag) source() string {
+	name := "--" + f.Name
+	if f.Alias != "" {
+		name = FlagArg(f.Alias)
+	}
+	if f.Type == BoolType && f.Value == "true" {
+		return name
+	}
+	value := f.Value
+	if strings.ContainsAny(value, " \t") && !strings.ContainsAny(value, `"'`) {
+		value = "'" + value + "'"
+	}
+	return name + " " + value
+}
+
+// FlagArg returns the flag name as written on the command line, eg: -p or --publish.
+func FlagArg(name string) string {
+	if len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
+// Flags returns the flags of the docker run command in the order they were parsed,
+// including unknown flags and flags dropped from the docker compose file.
+// It is populated by Parse.
+func (p *Parser) Flags() []Flag {
+	return p.flags
+}
+
+// Args returns the normalised docker run arguments: the flags in the --name=value form,
+// followed by the image and the command. It is populated by Parse.
+func (p *Parser) Args() []string {
+	args := make([]string, 0, len(p.flags)+1+len(p.service.Command))
+	for _, f := range p.flags {
+		args = append(args, f.Arg())
+	}
+	args = append(args, p.service.Image)
+	return append(args, p.service.Command...)
+}
+
+// recordFlag adds a parsed flag to the flags returned by Flags.
+func (p *Parser) recordFlag(name, value string, dockerFlag *DockerFlag) {
+	f := Flag{Name: p.vars.Name(name), Value: value}
+	if f.Name != name {
+		f.Alias = name
+	}
+	if dockerFlag != nil {
+		f.Type = dockerFlag.Type
+		f.ComposeName = strings.TrimPrefix(dockerFlag.ComposeName, servicePrefix)
+	}
+	p.flags = append(p.flags, f)
+}
+
+// FlagStatus tells how a known docker flag is converted into a docker compose file.
+type FlagStatus string
+
+// Flag statuses.
+const (
+	// FlagSupported flags are converted into a docker compose attribute.
+	FlagSupported FlagStatus = "supported"
+	// FlagDropped flags have no docker compose equivalent and are dropped.
+	FlagDropped FlagStatus = "dropped"
+	// FlagTodo flags are dropped but could be converted in the future.
+	FlagTodo FlagStatus = "todo"
+)
+
+// FlagInfo describes a docker flag known to the parser.
+type FlagInfo struct {
+	// Name is the long name of the flag and Aliases its shorthands and alternative names.
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases,omitempty"`
+	Type    FlagType `json:"type"`
+	// ComposePath is the dotted path of the service attribute the flag is converted into,
+	// eg: deploy.resources.limits.cpus, or $service for the flag naming the service.
+	// It is empty for flags which are not converted.
+	ComposePath string     `json:"composePath,omitempty"`
+	Status      FlagStatus `json:"status"`
+}
+
+// KnownFlags returns the docker run flags known to the parser, sorted by name.
+func KnownFlags() []FlagInfo {
+	return newVariables().info()
+}
+
+// KnownServiceCreateFlags returns the docker service create flags known to the parser, sorted by name.
+func KnownServiceCreateFlags() []FlagInfo {
+	return newServiceVariables().info()
+}
+
+// info describes the flags of the variables, listing shorthands and aliases as aliases of the flag they refer to.
+func (v *variables) info() []FlagInfo {
+	aliases := make(map[string][]string)
+	for name, f := range v.vars {
+		if f.Reference != "" {
+			aliases[f.Reference] = append(aliases[f.Reference], name)
+		}
+	}
+
+	var flags []FlagInfo
+	for name, f := range v.vars {
+		if f.Reference != "" {
+			continue
+		}
+		info := FlagInfo{
+			Name:        name,
+			Aliases:     aliases[name],
+			Type:        f.Type,
+			ComposePath: composePath(f.ComposeName),
+			Status:      FlagSupported,
+		}
+		sort.Strings(info.Aliases)
+		switch {
+		case f.ComposeName != "":
+		case f.Todo:
+			info.Status = FlagTodo
+		default:
+			info.Status = FlagDropped
+		}
+		flags = append(flags, info)
+	}
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Name < flags[j].Name })
+	return flags
+}
+
+// composePath returns the dotted service attribute path of a compose name in the flag mappings.
+func composePath(composeName string) string {
+	if composeName == serviceKey {
+		return "$service"
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(composeName, servicePrefix), ".$var")
+}
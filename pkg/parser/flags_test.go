@@ -0,0 +1,56 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlags(t *testing.T) {
+	p, err := New(`docker run -dit -p8080:80 --unknown=x -x y --rm=false -e "GREETING=hello world" nginx nginx -g "daemon off;"`)
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+
+	require.Equal(t, []Flag{
+		{Name: "detach", Alias: "d", Value: "true", Type: BoolType},
+		{Name: "interactive", Alias: "i", Value: "true", Type: BoolType, ComposeName: "stdin_open"},
+		{Name: "tty", Alias: "t", Value: "true", Type: BoolType, ComposeName: "tty"},
+		{Name: "publish", Alias: "p", Value: "8080:80", Type: ArrayType, ComposeName: "ports.$var"},
+		{Name: "unknown", Value: "x"},
+		{Name: "x", Value: "y"},
+		{Name: "rm", Value: "false", Type: BoolType},
+		{Name: "env", Alias: "e", Value: "GREETING=hello world", Type: MapType, ComposeName: "environment.$var"},
+	}, p.Flags())
+	require.False(t, p.Flags()[4].Known())
+
+	require.Equal(t, []string{
+		"--detach", "--interactive", "--tty", "--publish=8080:80", "--unknown=x", "-x=y", "--rm=false",
+		"--env=GREETING=hello world", "nginx", "nginx", "-g", "daemon off;",
+	}, p.Args())
+}
+
+func TestKnownFlags(t *testing.T) {
+	flags := KnownFlags()
+	byName := make(map[string]FlagInfo)
+	for _, f := range flags {
+		byName[f.Name] = f
+	}
+	require.Len(t, byName, len(flags))
+
+	require.Equal(t, FlagInfo{Name: "publish", Aliases: []string{"p"}, Type: ArrayType, ComposePath: "ports", Status: FlagSupported}, byName["publish"])
+	require.Equal(t, FlagInfo{Name: "network", Aliases: []string{"net"}, Type: StringType, ComposePath: "network_mode", Status: FlagSupported}, byName["network"])
+	require.Equal(t, FlagInfo{Name: "cpus", Type: Float64Type, ComposePath: "deploy.resources.limits.cpus", Status: FlagSupported}, byName["cpus"])
+	require.Equal(t, FlagDropped, byName["rm"].Status)
+	require.Equal(t, FlagTodo, byName["gpus"].Status)
+	require.NotContains(t, byName, "p")
+
+	b, err := json.Marshal(byName["env"])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"name":"env","aliases":["e"],"type":"map","composePath":"environment","status":"supported"}`, string(b))
+
+	services := KnownServiceCreateFlags()
+	require.Contains(t, services, FlagInfo{Name: "name", Type: StringType, ComposePath: "$service", Status: FlagSupported})
+	require.Contains(t, services, FlagInfo{Name: "replicas", Type: IntType, ComposePath: "deploy.replicas", Status: FlagSupported})
+	require.Contains(t, services, FlagInfo{Name: "secret", Type: StringType, Status: FlagTodo})
+}
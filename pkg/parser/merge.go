@@ -0,0 +1,218 @@
+package parser
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// argvKeys are the attributes holding a command line, which is overwritten as a whole when merged.
+var argvKeys = map[string]bool{
+	"command":    true,
+	"entrypoint": true,
+	"test":       true,
+}
+
+// mergeNode merges src into dst in place.
+// Mappings are merged key by key, sequences are extended with the items
+// of src which are not already present in dst and scalars are overwritten.
+// Command lines like command and entrypoint are overwritten too.
+// Comments, anchors and styles of the existing dst nodes are kept.
+// An alias in dst is replaced with a copy of the anchored node, which is merged
+// instead of the anchored node shared with the other aliases.
+func mergeNode(dst, src *yaml.Node) {
+	if dst.Kind == yaml.AliasNode && dst.Alias != nil {
+		resolveAlias(dst)
+	}
+	switch {
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode:
+		mergeMapping(dst, src)
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode:
+		mergeSequence(dst, src)
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.MappingNode:
+		// lists like environment and labels can be written as KEY=VALUE items
+		mergeMappingIntoList(dst, src)
+	default:
+		replaceNode(dst, src)
+	}
+}
+
+// replaceNode overwrites dst with src, keeping the comments and the style of a dst scalar.
+func replaceNode(dst, src *yaml.Node) {
+	dst.Kind = src.Kind
+	dst.Tag = src.Tag
+	dst.Value = src.Value
+	dst.Content = src.Content
+	dst.Alias = nil
+	if dst.Kind != yaml.ScalarNode || src.Style != 0 {
+		dst.Style = src.Style
+	}
+}
+
+func mergeMapping(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		if existing := mappingValue(dst, key.Value); existing != nil {
+			if argvKeys[key.Value] {
+				replaceNode(existing, value)
+				continue
+			}
+			mergeNode(existing, value)
+			continue
+		}
+		if inherited := mergedValue(dst, key.Value); inherited != nil && !argvKeys[key.Value] {
+			// the value overrides the one inherited through a << merge key, so it starts as a copy of it
+			merged := copyNode(inherited)
+			mergeNode(merged, value)
+			value = merged
+		}
+		// a comment trailing the mapping stays at its end
+		if n := len(dst.Content); n >= 2 && dst.Content[n-2].FootComment != "" {
+			key.FootComment, dst.Content[n-2].FootComment = dst.Content[n-2].FootComment, ""
+		}
+		dst.Content = append(dst.Content, key, value)
+	}
+}
+
+func mergeSequence(dst, src *yaml.Node) {
+	for _, item := range src.Content {
+		found := false
+		for _, existing := range dst.Content {
+			if nodeEqual(existing, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst.Content = append(dst.Content, item)
+		}
+	}
+}
+
+func mergeMappingIntoList(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i].Value, src.Content[i+1].Value
+		item := key
+		if value != "" {
+			item = key + "=" + value
+		}
+
+		replaced := false
+		for _, existing := range dst.Content {
+			if existing.Kind != yaml.ScalarNode {
+				continue
+			}
+			if existing.Value == key || strings.HasPrefix(existing.Value, key+"=") {
+				existing.Value = item
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			dst.Content = append(dst.Content, &yaml.Node{
+				Kind:  yaml.ScalarNode,
+				Value: item,
+			})
+		}
+	}
+}
+
+// mergedValue returns the value of key inherited through the << merge keys of the mapping node,
+// or nil if no merged mapping has the key. Earlier merged mappings take precedence.
+func mergedValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != "<<" {
+			continue
+		}
+		sources := []*yaml.Node{node.Content[i+1]}
+		if node.Content[i+1].Kind == yaml.SequenceNode {
+			sources = node.Content[i+1].Content
+		}
+		for _, source := range sources {
+			if source.Kind == yaml.AliasNode {
+				source = source.Alias
+			}
+			if source == nil || source.Kind != yaml.MappingNode {
+				continue
+			}
+			if value := mappingValue(source, key); value != nil {
+				return value
+			}
+			if value := mergedValue(source, key); value != nil {
+				return value
+			}
+		}
+	}
+	return nil
+}
+
+// resolveAlias replaces an alias node with a copy of the node it refers to, keeping its comments.
+func resolveAlias(node *yaml.Node) {
+	resolved := copyNode(node.Alias)
+	resolved.HeadComment = node.HeadComment
+	resolved.LineComment = node.LineComment
+	resolved.FootComment = node.FootComment
+	resolved.Line, resolved.Column = node.Line, node.Column
+	*node = *resolved
+}
+
+// copyNode returns a deep copy of a node without its anchors, as the anchors stay with the original node.
+// Aliases are copied as aliases, still referring to the original anchors.
+func copyNode(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.AliasNode && node.Alias != nil {
+		node = node.Alias
+	}
+	c := *node
+	c.Anchor = ""
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		if child.Kind == yaml.AliasNode {
+			alias := *child
+			c.Content[i] = &alias
+			continue
+		}
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// mappingValue returns the value node of key in the mapping node or nil if the key is not present.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	_, value := mappingEntry(node, key)
+	return value
+}
+
+// mappingEntry returns the key and value nodes of key in the mapping node or nils if the key is not present.
+func mappingEntry(node *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i], node.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// nodeEqual reports whether two nodes hold the same value, ignoring styles and comments.
+func nodeEqual(a, b *yaml.Node) bool {
+	if a.Kind == yaml.AliasNode {
+		a = a.Alias
+	}
+	if b.Kind == yaml.AliasNode {
+		b = b.Alias
+	}
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind != b.Kind || a.Value != b.Value || len(a.Content) != len(b.Content) {
+		return false
+	}
+	for i := range a.Content {
+		if !nodeEqual(a.Content[i], b.Content[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,62 @@
+package parser
+
+// Option configures a Parser.
+type Option func(p *Parser)
+
+// WithTarget sets the docker compose file format version written to the version key.
+// An empty version omits the version key as recommended by the Compose Specification.
+// Existing files passed to AppendToYAML and UpdateYAML keep their version.
+func WithTarget(version string) Option {
+	return func(p *Parser) {
+		p.project.Version = version
+	}
+}
+
+// WithServiceName sets the docker compose service name.
+// By default, the service is named after the image.
+func WithServiceName(name string) Option {
+	return func(p *Parser) {
+		p.service.Name = name
+	}
+}
+
+// WithStrict makes Parse return an error for unknown docker run flags and
+// for flags which cannot be represented in a docker compose file instead of dropping them.
+func WithStrict(strict bool) Option {
+	return func(p *Parser) {
+		p.strict = strict
+	}
+}
+
+// WithAnnotate adds comments to the attributes of the service naming the docker flag they were
+// converted from, eg: "# from -p 8080:80", and a comment listing the flags which were dropped.
+func WithAnnotate(annotate bool) Option {
+	return func(p *Parser) {
+		p.annotate = annotate
+	}
+}
+
+// WithIndent sets the number of spaces used to indent the docker compose file, from 2 to 9.
+// Render fails with other values.
+func WithIndent(spaces int) Option {
+	return func(p *Parser) {
+		p.indent = spaces
+	}
+}
+
+// WithEnvFileDir rewrites relative --env-file paths, which docker run resolves against
+// the working directory, to be relative to dir, the directory of the docker compose file.
+func WithEnvFileDir(dir string) Option {
+	return func(p *Parser) {
+		p.envFileDir = dir
+	}
+}
+
+// WithExtendsAnchor makes a service added by AppendToYAML extend the mapping with the given
+// anchor in the existing file using a merge key, eg: `<<: *common`.
+// Attributes of the service which are equal to the ones of the anchor are left out.
+func WithExtendsAnchor(anchor string) Option {
+	return func(p *Parser) {
+		p.extendsAnchor = anchor
+	}
+}
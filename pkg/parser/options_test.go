@@ -0,0 +1,126 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		opts     []Option
+		want     string
+		parseErr string
+	}{
+		{
+			name:    "target without version and custom indent",
+			command: "docker run -p 80:80 nginx",
+			opts:    []Option{WithTarget(""), WithIndent(2)},
+			want: `services:
+  nginx:
+    ports:
+      - 80:80
+    image: nginx
+`,
+		},
+		{
+			name:    "target version and service name",
+			command: "docker run nginx",
+			opts:    []Option{WithTarget("3.9"), WithServiceName("web")},
+			want: `version: "3.9"
+services:
+    web:
+        image: nginx
+`,
+		},
+		{
+			name:    "strict ignores docker run only flags",
+			command: "docker run -d --rm nginx",
+			opts:    []Option{WithStrict(true)},
+			want: `version: "3.8"
+services:
+    nginx:
+        image: nginx
+`,
+		},
+		{
+			name:     "strict fails on unknown flag",
+			command:  "docker run --unknown-flag value nginx",
+			opts:     []Option{WithStrict(true)},
+			parseErr: `unknown docker run flag "unknown-flag"`,
+		},
+		{
+			name:     "strict fails on unsupported flag",
+			command:  "docker run --gpus all nginx",
+			opts:     []Option{WithStrict(true)},
+			parseErr: `docker run flag "gpus" is not supported in docker compose`,
+		},
+		{
+			name:     "negative indent",
+			command:  "docker run nginx",
+			opts:     []Option{WithIndent(-2)},
+			parseErr: "invalid indent -2: must be between 2 and 9 spaces",
+		},
+		{
+			name:     "indent too small",
+			command:  "docker run nginx",
+			opts:     []Option{WithIndent(1)},
+			parseErr: "invalid indent 1: must be between 2 and 9 spaces",
+		},
+		{
+			name:     "indent too large",
+			command:  "docker run nginx",
+			opts:     []Option{WithIndent(10)},
+			parseErr: "invalid indent 10: must be between 2 and 9 spaces",
+		},
+		{
+			name:    "env files relative to the compose file directory",
+			command: "docker run --env-file config/app.env --env-file /etc/app.env nginx",
+			opts:    []Option{WithEnvFileDir("config")},
+			want: `version: "3.8"
+services:
+    nginx:
+        env_file:
+            - app.env
+            - /etc/app.env
+        image: nginx
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := New(tt.command, tt.opts...)
+			require.NoError(t, err)
+			err = p.Parse()
+			if tt.parseErr != "" {
+				require.ErrorContains(t, err, tt.parseErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, p.String())
+		})
+	}
+}
+
+func TestAppendToYAMLOptions(t *testing.T) {
+	compose := `version: "3.8"
+services:
+  db:
+    image: postgres
+`
+	p, err := AppendToYAML([]byte(compose), "docker run -p 80:80 nginx", WithServiceName("web"), WithIndent(2), WithTarget("3.9"))
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+	require.Equal(t, `version: "3.8"
+services:
+  db:
+    image: postgres
+  web:
+    ports:
+      - 80:80
+    image: nginx
+`, p.String())
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"errors"
 	"fmt"
+	"path/filepath"
 	"strconv"
 	"strings"
 
@@ -15,170 +16,230 @@ var (
 	errSkipFlag    = errors.New("skip flag")
 )
 
-// Parser parses a docker run command into a docker compose file format.
-type Parser struct {
-	document     *yaml.Node
-	version      string
-	_serviceName string
+// runOnlyFlags only change the behaviour of the docker run or docker service create
+// command itself and are not reported as unsupported in strict mode.
+var runOnlyFlags = map[string]bool{
+	"detach":             true,
+	"no-resolve-image":   true,
+	"quiet":              true,
+	"rm":                 true,
+	"sig-proxy":          true,
+	"with-registry-auth": true,
+}
+
+// Docker commands converted by the parser.
+const (
+	dockerRun           = "docker run"
+	dockerServiceCreate = "docker service create"
+)
 
-	refs    map[string]*yaml.Node
-	vars    *variables
-	command []string
+// servicePrefix is the compose path prefix of the service attributes in the flag mappings.
+const servicePrefix = "^services.$service."
+
+// Parser parses a docker run or docker service create command into a docker compose file format.
+type Parser struct {
+	project *Project
+	service *ServiceConfig
+
+	// existing is the docker compose file the service is added to or merged into.
+	existing *existingDocument
+	// mergeKey and mergeTarget are the existing service nodes the parsed flags are merged into.
+	mergeKey    *yaml.Node
+	mergeTarget *yaml.Node
+
+	// dockerCommand is the docker command parsed, docker run or docker service create.
+	dockerCommand string
+	vars          *variables
+	command       []string
+	flags         []Flag
+
+	strict        bool
+	annotate      bool
+	indent        int
+	envFileDir    string
+	extendsAnchor string
 
 	yamlBytes []byte
 }
 
 // SetVersion sets the docker compose version.
+//
+// Deprecated: use WithTarget.
 func (p *Parser) SetVersion(v string) {
-	p.version = v
+	p.project.Version = v
 }
 
 // SetServiceName sets the docker compose service name.
+//
+// Deprecated: use WithServiceName.
 func (p *Parser) SetServiceName(name string) {
-	p._serviceName = name
+	p.service.Name = name
 }
 
 // serviceName returns the docker compose service name.
 func (p *Parser) serviceName() string {
-	if p._serviceName == "" {
+	if p.service.Name == "" {
 		return defaultServiceName
 	}
-	return p._serviceName
+	return p.service.Name
 }
 
-// New creates a new Parser.
-func New(s string) (*Parser, error) {
-	p, err := newParser(s)
-	if err != nil {
-		return nil, err
-	}
+// Project returns the docker compose project populated by Parse.
+func (p *Parser) Project() *Project {
+	return p.project
+}
 
-	containerTitleNode := &yaml.Node{
-		Kind:  yaml.ScalarNode,
-		Value: defaultServiceName,
-	}
-
-	containerNode := &yaml.Node{
-		Kind:    yaml.MappingNode,
-		Content: []*yaml.Node{},
-	}
-
-	servicesNode := &yaml.Node{
-		Kind: yaml.MappingNode,
-		Content: []*yaml.Node{
-			containerTitleNode,
-			containerNode,
-		},
-	}
-
-	p.document = &yaml.Node{
-		Kind: yaml.DocumentNode,
-		Content: []*yaml.Node{
-			{
-				Kind: yaml.MappingNode,
-				Content: []*yaml.Node{
-					{
-						Kind:  yaml.ScalarNode,
-						Value: "version",
-					},
-					{
-						Kind:  yaml.ScalarNode,
-						Value: p.version,
-						Style: yaml.DoubleQuotedStyle,
-					},
-					{
-						Kind:  yaml.ScalarNode,
-						Value: "services",
-					},
-					servicesNode,
-				},
-			},
-		},
-	}
-
-	p.refs["^services"] = servicesNode
-	p.refs["$service"] = containerNode
-	p.refs["$serviceTitleNode"] = containerTitleNode
+// Service returns the docker compose service populated by Parse.
+func (p *Parser) Service() *ServiceConfig {
+	return p.service
+}
 
-	return p, nil
+// New creates a new Parser for a docker run command.
+// A docker service create command is converted into a service for docker stack deploy.
+func New(s string, opts ...Option) (*Parser, error) {
+	return newParser(s, opts)
+}
+
+// NewArgs creates a new Parser for the arguments of a docker run command,
+// the flags followed by the image and the command, without "docker run".
+// Unlike New, the arguments are not split or unquoted.
+func NewArgs(args []string, opts ...Option) (*Parser, error) {
+	if len(args) == 0 {
+		return nil, errors.New("empty docker command")
+	}
+	return newArgsParser(append([]string(nil), args...), opts), nil
 }
 
 // AppendToYAML converts a docker run command into a docker compose file format
 // and appends it to an existing docker compose file.
 // If the file is empty, it will create a new docker compose file.
-func AppendToYAML(b []byte, command string) (*Parser, error) {
+func AppendToYAML(b []byte, command string, opts ...Option) (*Parser, error) {
 	if len(b) == 0 {
-		return New(command)
+		return New(command, opts...)
 	}
 
-	p, err := newParser(command)
+	p, err := newParser(command, opts)
 	if err != nil {
 		return nil, err
 	}
 
-	var yamlDoc yaml.Node
+	if err := p.loadDocument(b); err != nil {
+		return nil, err
+	}
 
-	if err := yaml.Unmarshal(b, &yamlDoc); err != nil {
-		return nil, fmt.Errorf("failed to parse docker compose file: %w", err)
+	return p, nil
+}
+
+// UpdateYAML converts a docker run command into a docker compose service and merges it
+// into the existing service with the given name in a docker compose file.
+// Sequences are extended with values not yet present, maps are merged by key and
+// scalars are overwritten. Comments of the existing service are preserved.
+func UpdateYAML(b []byte, serviceName, command string, opts ...Option) (*Parser, error) {
+	if serviceName == "" {
+		return nil, errors.New("service name is required")
 	}
 
-	p.document = &yamlDoc
+	p, err := newParser(command, opts)
+	if err != nil {
+		return nil, err
+	}
 
-	if p.document == nil || len(p.document.Content) == 0 {
-		return nil, errors.New("invalid docker compose file")
+	if err := p.loadDocument(b); err != nil {
+		return nil, err
 	}
 
-	for i, node := range p.document.Content[0].Content {
-		if strings.ToLower(node.Value) == "services" {
-			p.refs["^services"] = p.document.Content[0].Content[i+1]
-			break
+	p.mergeKey, p.mergeTarget = mappingEntry(p.existing.services, serviceName)
+	if p.mergeTarget == nil {
+		return nil, fmt.Errorf("service %q not found in docker compose file", serviceName)
+	}
+	if p.mergeTarget.Kind != yaml.MappingNode && p.mergeTarget.Kind != yaml.AliasNode {
+		// a service declared without any attributes, eg: `api:`
+		*p.mergeTarget = yaml.Node{
+			Kind:        yaml.MappingNode,
+			HeadComment: p.mergeTarget.HeadComment,
+			LineComment: p.mergeTarget.LineComment,
+			FootComment: p.mergeTarget.FootComment,
 		}
 	}
 
-	if p.refs["^services"] == nil {
-		return nil, errors.New("invalid docker compose file: missing services node")
-	}
+	p.SetServiceName(serviceName)
 
-	containerTitleNode := &yaml.Node{
-		Kind:  yaml.ScalarNode,
-		Value: defaultServiceName,
+	return p, nil
+}
+
+// loadDocument parses an existing docker compose file the service is added to or merged into.
+func (p *Parser) loadDocument(b []byte) error {
+	var yamlDoc yaml.Node
+
+	if err := yaml.Unmarshal(b, &yamlDoc); err != nil {
+		return fmt.Errorf("failed to parse docker compose file: %w", err)
 	}
 
-	containerNode := &yaml.Node{
-		Kind:    yaml.MappingNode,
-		Content: []*yaml.Node{},
+	if len(yamlDoc.Content) == 0 || yamlDoc.Content[0].Kind != yaml.MappingNode {
+		return errors.New("invalid docker compose file")
 	}
 
-	p.refs["^services"].Content = append(p.refs["^services"].Content, containerTitleNode, containerNode)
-	p.refs["$service"] = containerNode
-	p.refs["$serviceTitleNode"] = containerTitleNode
+	root := yamlDoc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if strings.ToLower(root.Content[i].Value) == "services" {
+			p.existing = &existingDocument{
+				source:      b,
+				root:        &yamlDoc,
+				servicesKey: root.Content[i],
+				services:    root.Content[i+1],
+			}
+			return nil
+		}
+	}
 
-	return p, nil
+	return errors.New("invalid docker compose file: missing services node")
 }
 
 // setup sets up the parser.
-func newParser(s string) (*Parser, error) {
-	s = strings.TrimPrefix(strings.TrimSpace(s), "docker run")
-	if s == "" {
-		return nil, errors.New("empty docker command")
+func newParser(s string, opts []Option) (*Parser, error) {
+	s = strings.TrimSpace(s)
+	dockerCommand := dockerRun
+	if rest, ok := strings.CutPrefix(s, dockerServiceCreate); ok {
+		s, dockerCommand = rest, dockerServiceCreate
+	} else {
+		s = strings.TrimPrefix(s, dockerRun)
 	}
-
-	p := &Parser{
-		version: composeVersion,
-		refs:    make(map[string]*yaml.Node),
-		vars:    newVariables(),
+	if strings.TrimSpace(s) == "" {
+		return nil, errors.New("empty docker command")
 	}
 
 	command, err := parseArgs(s)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse docker run command: %w", err)
+		return nil, fmt.Errorf("failed to parse %s command: %w", dockerCommand, err)
 	}
-	p.command = command
 
+	p := newArgsParser(command, opts)
+	if dockerCommand == dockerServiceCreate {
+		p.dockerCommand, p.vars = dockerServiceCreate, newServiceVariables()
+	}
 	return p, nil
 }
 
-// Parse parses the docker run command into a docker compose file format.
+func newArgsParser(command []string, opts []Option) *Parser {
+	p := &Parser{
+		service:       &ServiceConfig{},
+		dockerCommand: dockerRun,
+		vars:          newVariables(),
+		command:       command,
+	}
+	p.project = &Project{
+		Version:  composeVersion,
+		Services: []*ServiceConfig{p.service},
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	return p
+}
+
+// Parse parses the docker command into a docker compose file format.
 func (p *Parser) Parse() error {
 	var parseErr error
 	for {
@@ -195,36 +256,34 @@ func (p *Parser) Parse() error {
 			break
 		}
 
-		if dockerFlag := p.vars.Get(flag); dockerFlag != nil {
-			if dockerFlag.ComposeName == "" {
-				continue
+		dockerFlag := p.vars.Get(flag)
+		p.recordFlag(flag, value, dockerFlag)
+		if dockerFlag == nil {
+			// TODO: what do we do with an unknown flag?
+			if p.strict {
+				return fmt.Errorf("unknown %s flag %q", p.dockerCommand, flag)
 			}
+			continue
+		}
 
-			composePath := strings.Split(dockerFlag.ComposeName, ".")
-
-			parent := p.document
-			for len(composePath) > 0 {
-				key := composePath[0]
-				composePath = composePath[1:]
-				val := value
-
-				kind := dockerFlag.Type
+		if dockerFlag.ComposeName == "" {
+			if p.strict && !runOnlyFlags[p.vars.Name(flag)] {
+				return fmt.Errorf("%s flag %q is not supported in docker compose", p.dockerCommand, flag)
+			}
+			continue
+		}
 
-				cNode := p.refs[key]
-				if ftype := p.vars.GetType(key); !ftype.IsZero() {
-					kind = ftype
-				}
-				if cNode == nil {
-					cNode, err = p.addNode(parent, flag, key, val, kind)
-					if err != nil {
-						return err
-					}
-				}
-				parent = cNode
+		if dockerFlag.ComposeName == serviceKey {
+			if p.service.Name == "" {
+				p.SetServiceName(trimQuotes(value))
 			}
+			continue
 		}
 
-		// TODO: what do we do with an unknown flag?
+		composePath := strings.TrimPrefix(dockerFlag.ComposeName, servicePrefix)
+		if err := p.service.setFlag(composePath, dockerFlag.Type, value); err != nil {
+			return fmt.Errorf("invalid value %s for %s flag %q: %w", value, p.dockerCommand, flag, err)
+		}
 	}
 
 	if errors.Is(parseErr, errNoMoreFlags) {
@@ -235,116 +294,166 @@ func (p *Parser) Parse() error {
 		return parseErr
 	}
 
-	p.yamlBytes, parseErr = yaml.Marshal(p.document)
-	return parseErr
+	p.SetServiceName(p.serviceName())
+	if p.annotate {
+		p.service.annotations = p.flags
+	}
+
+	if p.dockerCommand == dockerServiceCreate {
+		p.declareNetworks()
+		p.declareVolumes()
+	}
+
+	if p.envFileDir != "" {
+		if err := p.resolveEnvFiles(); err != nil {
+			return err
+		}
+	}
+
+	_, err := p.Render()
+	return err
 }
 
-func trimQuotes(s string) string {
-	return strings.Trim(s, `"'`)
+// resolveEnvFiles rewrites relative env_file paths to be relative to envFileDir.
+func (p *Parser) resolveEnvFiles() error {
+	dir, err := filepath.Abs(p.envFileDir)
+	if err != nil {
+		return err
+	}
+	for i, file := range p.service.EnvFile {
+		if filepath.IsAbs(file) {
+			continue
+		}
+		abs, err := filepath.Abs(file)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil {
+			return err
+		}
+		p.service.EnvFile[i] = filepath.ToSlash(rel)
+	}
+	return nil
 }
 
-func (p *Parser) addNode(parent *yaml.Node, flag, key, value string, ftype FlagType) (*yaml.Node, error) {
-	kind := ftype.YamlKind()
-	valueNode := &yaml.Node{}
-	mainKey := key
-
-	value = trimQuotes(value)
-
-	if key == "$var" {
-		key = ""
-		switch ftype {
-		case MapType:
-			kind = yaml.ScalarNode
-			vals := strings.SplitN(value, "=", 2)
-			switch len(vals) {
-			case 1:
-				key = vals[0]
-				value = ""
-			case 2:
-				key, value = vals[0], trimQuotes(vals[1])
-			default:
-				return nil, fmt.Errorf("invalid value %s for docker run flag %q", value, flag)
-			}
-		case ArrayType:
-			kind = yaml.ScalarNode
-		case UlimitType:
-			ulimit, err := ParseUlimit(value)
-			if err != nil {
-				return nil, err
-			}
-			key, valueNode = ulimit.YAML()
-		case MountType:
-			mount, err := ParseMount(value)
-			if err != nil {
-				return nil, err
-			}
-			key, valueNode = mount.YAML()
+// Render renders the project into a docker compose file.
+// When the parser was created with AppendToYAML or UpdateYAML the service is
+// added to or merged into the existing file, leaving the rest of the file untouched.
+// Render can be called again after modifying the Project or Service to update the output.
+func (p *Parser) Render() ([]byte, error) {
+	if p.indent != 0 && (p.indent < minIndent || p.indent > maxIndent) {
+		return nil, fmt.Errorf("invalid indent %d: must be between %d and %d spaces", p.indent, minIndent, maxIndent)
+	}
+
+	var b []byte
+	var err error
+
+	switch {
+	case p.mergeTarget != nil:
+		b, err = p.renderMerge()
+	case p.existing != nil:
+		b, err = p.renderAppend()
+	case p.extendsAnchor != "":
+		err = fmt.Errorf("anchor %q not found: services can only extend anchors of an existing docker compose file", p.extendsAnchor)
+	default:
+		indent := p.indent
+		if indent == 0 {
+			indent = defaultIndent
 		}
+		b, err = encodeNode(p.project.YAML(), indent)
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	valueNode.Value = value
-	valueNode.Kind = kind
+	p.yamlBytes = b
+	return b, nil
+}
+
+// documentIndent returns the indentation for the existing document.
+func (p *Parser) documentIndent() int {
+	if p.indent > 0 {
+		return p.indent
+	}
+	if indent := p.existing.indent(); indent > 0 {
+		return indent
+	}
+	return defaultIndent
+}
 
-	if key != "" {
-		parent.Content = append(parent.Content, &yaml.Node{
-			Kind:  yaml.ScalarNode,
-			Value: key,
-		})
+func (p *Parser) renderAppend() ([]byte, error) {
+	d := p.existing
+	key, node := p.service.YAML()
+	indent := p.documentIndent()
 
-		if mainKey != "$var" {
-			p.refs[key] = valueNode
+	if p.extendsAnchor != "" {
+		var err error
+		node, err = d.extend(node, p.extendsAnchor)
+		if err != nil {
+			return nil, err
 		}
 	}
 
-	parent.Content = append(parent.Content, valueNode)
+	if d.spliceable(len(d.services.Content) / 2) {
+		return d.insertService(key, node, indent)
+	}
 
-	return valueNode, nil
+	// the services block is empty or in flow style, so the whole file is encoded again
+	services := *d.services
+	defer func() { *d.services = services }()
+
+	if d.services.Kind != yaml.MappingNode {
+		*d.services = yaml.Node{Kind: yaml.MappingNode}
+	}
+	content := d.services.Content
+	d.services.Content = append(content[:len(content):len(content)], scalarNode(key), node)
+
+	return encodeNode(d.root, indent)
+}
+
+func (p *Parser) renderMerge() ([]byte, error) {
+	d := p.existing
+	_, node := p.service.YAML()
+	mergeNode(p.mergeTarget, node)
+
+	indent := p.indent
+	if indent == 0 && len(p.mergeTarget.Content) > 0 && p.mergeTarget.Content[0].Line > p.mergeKey.Line {
+		indent = p.mergeTarget.Content[0].Column - p.mergeKey.Column
+	}
+	if indent <= 0 {
+		indent = p.documentIndent()
+	}
+
+	if d.spliceable(len(d.services.Content) / 2) {
+		return d.replaceService(p.mergeKey, p.mergeTarget, indent)
+	}
+	return encodeNode(d.root, indent)
+}
+
+func trimQuotes(s string) string {
+	return strings.Trim(s, `"'`)
 }
 
 func (p *Parser) parseImage() error {
 	image := p.command[0]
-	imageNode := []*yaml.Node{
-		{
-			Kind:  yaml.ScalarNode,
-			Value: "image",
-		},
-		{
-			Kind:  yaml.ScalarNode,
-			Value: image,
-		},
-	}
 	// if the image name is for example, profclems/glab:latest or just profclems/glab
 	//  we need to make sure the service name will be the last word after slash but without the
 	//  tag version, like just "glab" in the example above
 	p.command = p.command[1:] // the rest are commands
 	ns := strings.Split(image, "/")
 
-	if p.serviceName() == defaultServiceName {
+	if p.service.Name == "" {
 		p.SetServiceName(strings.SplitN(ns[len(ns)-1], ":", 2)[0])
 	}
 
-	p.refs["$serviceTitleNode"].Value = p.serviceName()
-	p.refs["$service"].Content = append(p.refs["$service"].Content, imageNode...)
+	p.service.Image = image
+	p.service.record("image")
 
 	if len(p.command) > 0 {
-		commandsNode := &yaml.Node{
-			Kind: yaml.SequenceNode,
-		}
-
-		for len(p.command) > 0 {
-			c := p.command[0]
-			commandsNode.Content = append(commandsNode.Content, &yaml.Node{
-				Kind:  yaml.ScalarNode,
-				Value: c,
-			})
-
-			p.command = p.command[1:]
-		}
-
-		p.refs["$service"].Content = append(p.refs["$service"].Content, &yaml.Node{
-			Kind:  yaml.ScalarNode,
-			Value: "command",
-		}, commandsNode)
+		p.service.Command = append(p.service.Command, p.command...)
+		p.service.record("command")
+		p.command = nil
 	}
 
 	return nil
@@ -419,7 +528,7 @@ func (p *Parser) parseOneFlag() (string, string, error) {
 		if hasValue {
 			pv, err := strconv.ParseBool(value)
 			if err != nil {
-				return "", "", fmt.Errorf("invalid value %q for docker run flag %q: %s", value, name, err)
+				return "", "", fmt.Errorf("invalid value %q for %s flag %q: %s", value, p.dockerCommand, name, err)
 			}
 			value = strconv.FormatBool(pv)
 			return name, value, nil
@@ -457,7 +566,7 @@ func (p *Parser) parseOneFlag() (string, string, error) {
 		}
 
 		if !hasValue {
-			return "", "", fmt.Errorf("docker run flag %q is missing an argument", name)
+			return "", "", fmt.Errorf("%s flag %q is missing an argument", p.dockerCommand, name)
 		}
 	}
 
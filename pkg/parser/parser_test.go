@@ -73,6 +73,31 @@ services:
             ENV2: VALUE2
             ENV3: VALUE3
         image: alpine
+`,
+		},
+		{
+			name:    "labels are a map",
+			command: "docker run -l com.example.team=web --label tier=frontend -l debug -l tier=backend alpine",
+			want: `version: "3.8"
+services:
+    alpine:
+        labels:
+            com.example.team: web
+            tier: backend
+            debug:
+        image: alpine
+`,
+		},
+		{
+			name:    "label files",
+			command: "docker run --label-file ./labels --label-file ./more.labels alpine",
+			want: `version: "3.8"
+services:
+    alpine:
+        label_file:
+            - ./labels
+            - ./more.labels
+        image: alpine
 `,
 		},
 		{
@@ -383,3 +408,219 @@ services:
 		})
 	}
 }
+
+func TestUpdateYAML(t *testing.T) {
+	compose := `version: "3.8"
+services:
+    # the api service
+    api:
+        image: api:1.0 # pinned
+        environment:
+            FOO: "0"
+            BAR: baz
+        ports:
+            - 8080:80
+    db:
+        image: postgres
+        environment:
+            - POSTGRES_USER=admin
+`
+	tests := []struct {
+		name        string
+		compose     string
+		serviceName string
+		command     string
+		want        string
+		wantErr     string
+	}{
+		{
+			name:        "merge flags into existing service",
+			serviceName: "api",
+			command:     "docker run -e FOO=1 -e NEW=value -p 8080:80 -p 9000:9000 --restart always",
+			want: `version: "3.8"
+services:
+    # the api service
+    api:
+        image: api:1.0 # pinned
+        environment:
+            FOO: "1"
+            BAR: baz
+            NEW: value
+        ports:
+            - 8080:80
+            - 9000:9000
+        restart: always
+    db:
+        image: postgres
+        environment:
+            - POSTGRES_USER=admin
+`,
+		},
+		{
+			name:        "merge environment into list syntax and overwrite image",
+			serviceName: "db",
+			command:     "docker run -e POSTGRES_USER=root -e POSTGRES_DB=app postgres:15",
+			want: `version: "3.8"
+services:
+    # the api service
+    api:
+        image: api:1.0 # pinned
+        environment:
+            FOO: "0"
+            BAR: baz
+        ports:
+            - 8080:80
+    db:
+        image: postgres:15
+        environment:
+            - POSTGRES_USER=root
+            - POSTGRES_DB=app
+`,
+		},
+		{
+			name: "overwrite command and entrypoint",
+			compose: `services:
+    web:
+        image: nginx
+        entrypoint: [/docker-entrypoint.sh]
+        command: [nginx, -g, "daemon off;"]
+`,
+			serviceName: "web",
+			command:     "docker run --entrypoint /bin/sh nginx sleep 10",
+			want: `services:
+    web:
+        image: nginx
+        entrypoint: /bin/sh
+        command:
+            - sleep
+            - 10
+`,
+		},
+		{
+			name: "merge into an aliased mapping",
+			compose: `x-labels: &lbl
+    team: core
+services:
+    api:
+        image: api
+        labels: *lbl
+    worker:
+        image: worker
+        labels: *lbl
+`,
+			serviceName: "api",
+			command:     "docker run -l tier=web api",
+			want: `x-labels: &lbl
+    team: core
+services:
+    api:
+        image: api
+        labels:
+            team: core
+            tier: web
+    worker:
+        image: worker
+        labels: *lbl
+`,
+		},
+		{
+			name: "merge into a mapping inherited with a merge key",
+			compose: `x-common: &common
+    restart: always
+    environment:
+        TZ: UTC
+services:
+    api:
+        <<: *common
+        image: api
+`,
+			serviceName: "api",
+			command:     "docker run -e A=1 --restart no api",
+			want: `x-common: &common
+    restart: always
+    environment:
+        TZ: UTC
+services:
+    api:
+        <<: *common
+        image: api
+        environment:
+            TZ: UTC
+            A: 1
+        restart: no
+`,
+		},
+		{
+			name: "merge into an aliased service",
+			compose: `x-base: &base
+    image: api
+    environment:
+        TZ: UTC
+services:
+    api: *base
+`,
+			serviceName: "api",
+			command:     "docker run -e A=1 api:2",
+			want: `x-base: &base
+    image: api
+    environment:
+        TZ: UTC
+services:
+    api:
+        image: api:2
+        environment:
+            TZ: UTC
+            A: 1
+`,
+		},
+		{
+			name:        "unknown service",
+			serviceName: "web",
+			command:     "docker run -e FOO=1",
+			wantErr:     `service "web" not found`,
+		},
+		{
+			name:        "missing service name",
+			serviceName: "",
+			command:     "docker run -e FOO=1",
+			wantErr:     "service name is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			input := compose
+			if tt.compose != "" {
+				input = tt.compose
+			}
+			parser, err := UpdateYAML([]byte(input), tt.serviceName, tt.command)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				require.Nil(t, parser)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, parser.Parse())
+			require.Equal(t, tt.want, parser.String())
+		})
+	}
+}
+
+func TestNewArgs(t *testing.T) {
+	parser, err := NewArgs([]string{"-e", `MESSAGE=it's "quoted" here`, "--name", "web", "alpine", "echo", "hello world"}, WithTarget(""))
+	require.NoError(t, err)
+	require.NoError(t, parser.Parse())
+	require.Equal(t, `services:
+    alpine:
+        environment:
+            MESSAGE: it's "quoted" here
+        container_name: web
+        image: alpine
+        command:
+            - echo
+            - hello world
+`, parser.String())
+
+	_, err = NewArgs(nil)
+	require.ErrorContains(t, err, "empty docker command")
+}
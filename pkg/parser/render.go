@@ -0,0 +1,276 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"gopkg.in/yaml.v3"
+)
+
+// YAML converts the Project to a yaml document node.
+func (p *Project) YAML() *yaml.Node {
+	root := &yaml.Node{
+		Kind: yaml.MappingNode,
+	}
+
+	if p.Version != "" {
+		root.Content = append(root.Content, scalarNode("version"), &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Value: p.Version,
+			Style: yaml.DoubleQuotedStyle,
+		})
+	}
+
+	servicesNode := &yaml.Node{
+		Kind: yaml.MappingNode,
+	}
+	for _, service := range p.Services {
+		key, value := service.YAML()
+		keyNode := scalarNode(key)
+		keyNode.HeadComment = service.Comment
+		servicesNode.Content = append(servicesNode.Content, keyNode, value)
+	}
+	root.Content = append(root.Content, scalarNode("services"), servicesNode)
+
+	if len(p.Networks) > 0 {
+		networksNode := &yaml.Node{Kind: yaml.MappingNode}
+		for _, network := range p.Networks {
+			key, value := network.YAML()
+			networksNode.Content = append(networksNode.Content, scalarNode(key), value)
+		}
+		root.Content = append(root.Content, scalarNode("networks"), networksNode)
+	}
+	if len(p.Volumes) > 0 {
+		volumesNode := &yaml.Node{Kind: yaml.MappingNode}
+		for _, volume := range p.Volumes {
+			key, value := volume.YAML()
+			volumesNode.Content = append(volumesNode.Content, scalarNode(key), value)
+		}
+		root.Content = append(root.Content, scalarNode("volumes"), volumesNode)
+	}
+
+	return &yaml.Node{
+		Kind:    yaml.DocumentNode,
+		Content: []*yaml.Node{root},
+	}
+}
+
+// YAML converts the ServiceConfig to a yaml.Node.
+// The key is the name of the service.
+func (s *ServiceConfig) YAML() (key string, value *yaml.Node) {
+	value = s.structNode(reflect.ValueOf(s).Elem(), "")
+	if value == nil {
+		value = &yaml.Node{
+			Kind:    yaml.MappingNode,
+			Content: []*yaml.Node{},
+		}
+	}
+	if len(s.annotations) > 0 {
+		s.annotate(value)
+	}
+	return s.Name, value
+}
+
+// YAML converts the NetworkConfig to a yaml.Node. The key is the name of the network.
+func (n *NetworkConfig) YAML() (key string, value *yaml.Node) {
+	return n.Name, fieldsNode(reflect.ValueOf(n).Elem())
+}
+
+// YAML converts the VolumeConfig to a yaml.Node. The key is the name of the volume.
+func (v *VolumeConfig) YAML() (key string, value *yaml.Node) {
+	return v.Name, fieldsNode(reflect.ValueOf(v).Elem())
+}
+
+// fieldsNode renders the non-empty fields of a struct in field order.
+// An empty struct is rendered as an empty mapping.
+func fieldsNode(v reflect.Value) *yaml.Node {
+	var s ServiceConfig
+	if node := s.structNode(v, ""); node != nil {
+		return node
+	}
+	return &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{}}
+}
+
+// position returns the position used to order the attribute at path.
+func (s *ServiceConfig) position(path string, fallback int) int {
+	if pos, ok := s.order[path]; ok {
+		return pos
+	}
+	return len(s.order) + fallback
+}
+
+type keyedNode struct {
+	key   string
+	value *yaml.Node
+	pos   int
+}
+
+// structNode renders the non-empty fields of a struct as a mapping node.
+// It returns nil if all fields are empty.
+func (s *ServiceConfig) structNode(v reflect.Value, prefix string) *yaml.Node {
+	var entries []keyedNode
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := yamlName(t.Field(i))
+		if name == "" || name == "-" || !t.Field(i).IsExported() {
+			continue
+		}
+		node := s.valueNode(v.Field(i), prefix+name)
+		if node == nil {
+			continue
+		}
+		entries = append(entries, keyedNode{name, node, s.position(prefix+name, i)})
+	}
+	return mappingNode(entries)
+}
+
+// valueNode renders a field value. It returns nil for empty values.
+func (s *ServiceConfig) valueNode(v reflect.Value, path string) *yaml.Node {
+	switch v.Type() {
+	case mappingType:
+		return v.Interface().(Mapping).YAML()
+	case ulimitsType:
+		ulimits := v.Interface().([]*Ulimit)
+		if len(ulimits) == 0 {
+			return nil
+		}
+		node := &yaml.Node{Kind: yaml.MappingNode}
+		for _, u := range ulimits {
+			key, value := u.YAML()
+			node.Content = append(node.Content, scalarNode(key), value)
+		}
+		return node
+	case servicePortType:
+		ports := v.Interface().([]ServicePort)
+		if len(ports) == 0 {
+			return nil
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, port := range ports {
+			node.Content = append(node.Content, port.YAML())
+		}
+		return node
+	case serviceVolumeType:
+		volumes := v.Interface().([]ServiceVolume)
+		if len(volumes) == 0 {
+			return nil
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for _, volume := range volumes {
+			node.Content = append(node.Content, volume.YAML())
+		}
+		return node
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil
+		}
+		if v.Elem().Kind() != reflect.Struct {
+			// optional values are rendered even when zero
+			return scalarNode(fmt.Sprint(v.Elem().Interface()))
+		}
+		return s.valueNode(v.Elem(), path)
+	case reflect.Struct:
+		return s.structNode(v, path+".")
+	case reflect.Map:
+		var entries []keyedNode
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for i, key := range keys {
+			node := s.valueNode(v.MapIndex(key), path+"."+key.String())
+			if node == nil {
+				node = &yaml.Node{Kind: yaml.MappingNode}
+			}
+			entries = append(entries, keyedNode{key.String(), node, s.position(path+"."+key.String(), i)})
+		}
+		return mappingNode(entries)
+	case reflect.Slice:
+		if v.Len() == 0 {
+			return nil
+		}
+		node := &yaml.Node{Kind: yaml.SequenceNode}
+		for i := 0; i < v.Len(); i++ {
+			if item := v.Index(i); item.Kind() != reflect.String {
+				if itemNode := s.valueNode(item, path); itemNode != nil {
+					node.Content = append(node.Content, itemNode)
+				}
+				continue
+			}
+			node.Content = append(node.Content, scalarNode(v.Index(i).String()))
+		}
+		return node
+	case reflect.String:
+		if v.String() == "" {
+			return nil
+		}
+		return scalarNode(v.String())
+	case reflect.Bool:
+		if !v.Bool() {
+			return nil
+		}
+		return scalarNode("true")
+	case reflect.Int64:
+		if v.Int() == 0 {
+			return nil
+		}
+		return scalarNode(strconv.FormatInt(v.Int(), 10))
+	case reflect.Float64:
+		if v.Float() == 0 {
+			return nil
+		}
+		return scalarNode(strconv.FormatFloat(v.Float(), 'f', -1, 64))
+	}
+	return nil
+}
+
+// YAML converts the Mapping to a yaml.Node. It returns nil for an empty Mapping.
+func (m Mapping) YAML() *yaml.Node {
+	if len(m) == 0 {
+		return nil
+	}
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, kv := range m {
+		node.Content = append(node.Content, scalarNode(kv.Key), scalarNode(kv.Value))
+	}
+	return node
+}
+
+// YAML converts the ServicePort to a yaml.Node.
+func (p ServicePort) YAML() *yaml.Node {
+	if p.Port != nil {
+		return fieldsNode(reflect.ValueOf(p.Port).Elem())
+	}
+	return scalarNode(p.Spec)
+}
+
+// YAML converts the ServiceVolume to a yaml.Node.
+func (v ServiceVolume) YAML() *yaml.Node {
+	if v.Mount != nil {
+		_, node := v.Mount.YAML()
+		return node
+	}
+	return scalarNode(v.Spec)
+}
+
+func mappingNode(entries []keyedNode) *yaml.Node {
+	if len(entries) == 0 {
+		return nil
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].pos < entries[j].pos })
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	for _, e := range entries {
+		node.Content = append(node.Content, scalarNode(e.key), e.value)
+	}
+	return node
+}
+
+func scalarNode(value string) *yaml.Node {
+	return &yaml.Node{
+		Kind:  yaml.ScalarNode,
+		Value: value,
+	}
+}
@@ -0,0 +1,572 @@
+package parser
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Project represents a docker compose file.
+type Project struct {
+	// Version is the docker compose file format version.
+	// The version key is omitted from the output when empty.
+	Version  string
+	Services []*ServiceConfig
+	// Networks and Volumes are the top-level networks and volumes, rendered after the services.
+	Networks []*NetworkConfig
+	Volumes  []*VolumeConfig
+}
+
+// Service returns the service with the given name or nil if there is no such service.
+func (p *Project) Service(name string) *ServiceConfig {
+	for _, s := range p.Services {
+		if s.Name == name {
+			return s
+		}
+	}
+	return nil
+}
+
+// Network returns the top-level network with the given name or nil if there is no such network.
+func (p *Project) Network(name string) *NetworkConfig {
+	for _, n := range p.Networks {
+		if n.Name == name {
+			return n
+		}
+	}
+	return nil
+}
+
+// Volume returns the top-level volume with the given name or nil if there is no such volume.
+func (p *Project) Volume(name string) *VolumeConfig {
+	for _, v := range p.Volumes {
+		if v.Name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// ConnectNetworks connects the services whose network mode is a top-level network to the network,
+// eg: after adding the networks created with docker network create.
+// The addresses and aliases set for the default network are moved to the network.
+func (p *Project) ConnectNetworks() {
+	for _, s := range p.Services {
+		if s.NetworkMode == "" || p.Network(s.NetworkMode) == nil {
+			continue
+		}
+		s.SetNetworks(map[string]*ServiceNetworkConfig{s.NetworkMode: s.Networks["default"]})
+	}
+}
+
+// ServiceConfig represents a docker compose service.
+// Field tags hold the docker compose attribute names according to
+// https://github.com/compose-spec/compose-spec/blob/master/spec.md
+type ServiceConfig struct {
+	// Name is the name of the service under the services key.
+	Name string `yaml:"-"`
+	// Comment is written above the service, eg: where the docker run command was found.
+	Comment string `yaml:"-"`
+
+	Annotations       Mapping                          `yaml:"annotations,omitempty"`
+	Attach            []string                         `yaml:"attach,omitempty"`
+	BlkioConfig       *BlkioConfig                     `yaml:"blkio_config,omitempty"`
+	Build             *BuildConfig                     `yaml:"build,omitempty"`
+	CapAdd            []string                         `yaml:"cap_add,omitempty"`
+	CapDrop           []string                         `yaml:"cap_drop,omitempty"`
+	CgroupParent      string                           `yaml:"cgroup_parent,omitempty"`
+	CgroupnsMode      string                           `yaml:"cgroupns_mode,omitempty"`
+	Command           []string                         `yaml:"command,omitempty"`
+	ContainerIDFile   string                           `yaml:"container_id_file,omitempty"`
+	ContainerName     string                           `yaml:"container_name,omitempty"`
+	CPUPeriod         int64                            `yaml:"cpu_period,omitempty"`
+	CPUQuota          int64                            `yaml:"cpu_quota,omitempty"`
+	CPURTPeriod       int64                            `yaml:"cpu_rt_period,omitempty"`
+	CPURTRuntime      int64                            `yaml:"cpu_rt_runtime,omitempty"`
+	CPUShares         int64                            `yaml:"cpu_shares,omitempty"`
+	Cpuset            string                           `yaml:"cpuset,omitempty"`
+	CpusetMems        string                           `yaml:"cpuset_mems,omitempty"`
+	Deploy            *DeployConfig                    `yaml:"deploy,omitempty"`
+	DeviceCgroupRules []string                         `yaml:"device_cgroup_rules,omitempty"`
+	Devices           []string                         `yaml:"devices,omitempty"`
+	DNS               []string                         `yaml:"dns,omitempty"`
+	DNSOpt            []string                         `yaml:"dns_opt,omitempty"`
+	DNSSearch         []string                         `yaml:"dns_search,omitempty"`
+	Domainname        string                           `yaml:"domainname,omitempty"`
+	Entrypoint        string                           `yaml:"entrypoint,omitempty"`
+	EnvFile           []string                         `yaml:"env_file,omitempty"`
+	Environment       Mapping                          `yaml:"environment,omitempty"`
+	Expose            []string                         `yaml:"expose,omitempty"`
+	ExtraHosts        []string                         `yaml:"extra_hosts,omitempty"`
+	GroupAdd          []string                         `yaml:"group_add,omitempty"`
+	Healthcheck       *HealthCheckConfig               `yaml:"healthcheck,omitempty"`
+	Hostname          string                           `yaml:"hostname,omitempty"`
+	Image             string                           `yaml:"image,omitempty"`
+	Init              bool                             `yaml:"init,omitempty"`
+	Ipc               string                           `yaml:"ipc,omitempty"`
+	Isolation         string                           `yaml:"isolation,omitempty"`
+	LabelFile         []string                         `yaml:"label_file,omitempty"`
+	Labels            Mapping                          `yaml:"labels,omitempty"`
+	Links             []string                         `yaml:"links,omitempty"`
+	Logging           *LoggingConfig                   `yaml:"logging,omitempty"`
+	MacAddress        string                           `yaml:"mac_address,omitempty"`
+	MemSwappiness     *int64                           `yaml:"mem_swappiness,omitempty"`
+	MemswapLimit      string                           `yaml:"memswap_limit,omitempty"`
+	NetworkMode       string                           `yaml:"network_mode,omitempty"`
+	Networks          map[string]*ServiceNetworkConfig `yaml:"networks,omitempty"`
+	OomKillDisable    bool                             `yaml:"oom_kill_disable,omitempty"`
+	OomScoreAdj       int64                            `yaml:"oom_score_adj,omitempty"`
+	Pid               string                           `yaml:"pid,omitempty"`
+	PidsLimit         int64                            `yaml:"pids_limit,omitempty"`
+	Platform          string                           `yaml:"platform,omitempty"`
+	Ports             []ServicePort                    `yaml:"ports,omitempty"`
+	Privileged        bool                             `yaml:"privileged,omitempty"`
+	ReadOnly          bool                             `yaml:"read_only,omitempty"`
+	Restart           string                           `yaml:"restart,omitempty"`
+	Runtime           string                           `yaml:"runtime,omitempty"`
+	SecurityOpt       []string                         `yaml:"security_opt,omitempty"`
+	ShmSize           string                           `yaml:"shm_size,omitempty"`
+	StdinOpen         bool                             `yaml:"stdin_open,omitempty"`
+	StopGracePeriod   string                           `yaml:"stop_grace_period,omitempty"`
+	StopSignal        string                           `yaml:"stop_signal,omitempty"`
+	StorageOpt        Mapping                          `yaml:"storage_opt,omitempty"`
+	Sysctls           Mapping                          `yaml:"sysctls,omitempty"`
+	Tmpfs             []string                         `yaml:"tmpfs,omitempty"`
+	Tty               bool                             `yaml:"tty,omitempty"`
+	Ulimits           []*Ulimit                        `yaml:"ulimits,omitempty"`
+	User              string                           `yaml:"user,omitempty"`
+	UsernsMode        string                           `yaml:"userns_mode,omitempty"`
+	Uts               string                           `yaml:"uts,omitempty"`
+	Volumes           []ServiceVolume                  `yaml:"volumes,omitempty"`
+	VolumesFrom       []string                         `yaml:"volumes_from,omitempty"`
+	WorkingDir        string                           `yaml:"working_dir,omitempty"`
+
+	// annotations are the flags the service was converted from, set when the
+	// attributes are annotated with the flags producing them.
+	annotations []Flag
+
+	// order holds the position at which each attribute path was first set
+	// so that attributes are rendered in the order of the docker run flags.
+	// Attributes without a position are rendered after them in field order.
+	order map[string]int
+}
+
+// BlkioConfig represents the blkio_config of a service.
+type BlkioConfig struct {
+	Weight          int64    `yaml:"weight,omitempty"`
+	WeightDevice    []string `yaml:"weight_device,omitempty"`
+	DeviceReadBps   []string `yaml:"device_read_bps,omitempty"`
+	DeviceReadIOps  []string `yaml:"device_read_iops,omitempty"`
+	DeviceWriteBps  []string `yaml:"device_write_bps,omitempty"`
+	DeviceWriteIOps []string `yaml:"device_write_iops,omitempty"`
+}
+
+// DeployConfig represents the deploy configuration of a service.
+// Apart from the resources, it is only used when deploying to a swarm with docker stack deploy.
+type DeployConfig struct {
+	Mode           string         `yaml:"mode,omitempty"`
+	Replicas       *int64         `yaml:"replicas,omitempty"`
+	EndpointMode   string         `yaml:"endpoint_mode,omitempty"`
+	Labels         Mapping        `yaml:"labels,omitempty"`
+	Placement      *Placement     `yaml:"placement,omitempty"`
+	Resources      *Resources     `yaml:"resources,omitempty"`
+	RestartPolicy  *RestartPolicy `yaml:"restart_policy,omitempty"`
+	RollbackConfig *UpdateConfig  `yaml:"rollback_config,omitempty"`
+	UpdateConfig   *UpdateConfig  `yaml:"update_config,omitempty"`
+}
+
+// Placement represents the constraints and preferences for the nodes the tasks of a service run on.
+type Placement struct {
+	Constraints []string              `yaml:"constraints,omitempty"`
+	Preferences []PlacementPreference `yaml:"preferences,omitempty"`
+	MaxReplicas int64                 `yaml:"max_replicas_per_node,omitempty"`
+}
+
+// PlacementPreference spreads the tasks of a service evenly over the values of a node label.
+type PlacementPreference struct {
+	Spread string `yaml:"spread,omitempty"`
+}
+
+// RestartPolicy represents how the tasks of a service are restarted when they exit.
+type RestartPolicy struct {
+	Condition   string `yaml:"condition,omitempty"`
+	Delay       string `yaml:"delay,omitempty"`
+	MaxAttempts *int64 `yaml:"max_attempts,omitempty"`
+	Window      string `yaml:"window,omitempty"`
+}
+
+// UpdateConfig represents how a service is updated or rolled back.
+type UpdateConfig struct {
+	Parallelism     *int64  `yaml:"parallelism,omitempty"`
+	Delay           string  `yaml:"delay,omitempty"`
+	FailureAction   string  `yaml:"failure_action,omitempty"`
+	Monitor         string  `yaml:"monitor,omitempty"`
+	MaxFailureRatio float64 `yaml:"max_failure_ratio,omitempty"`
+	Order           string  `yaml:"order,omitempty"`
+}
+
+// Resources represents the resource constraints of a service.
+type Resources struct {
+	Limits       *Resource `yaml:"limits,omitempty"`
+	Reservations *Resource `yaml:"reservations,omitempty"`
+}
+
+// Resource represents a resource limit or reservation.
+type Resource struct {
+	CPUs   float64 `yaml:"cpus,omitempty"`
+	Memory string  `yaml:"memory,omitempty"`
+	Pids   int64   `yaml:"pids,omitempty"`
+}
+
+// HealthCheckConfig represents the healthcheck of a service.
+type HealthCheckConfig struct {
+	Test        string `yaml:"test,omitempty"`
+	Interval    string `yaml:"interval,omitempty"`
+	Timeout     string `yaml:"timeout,omitempty"`
+	Retries     int64  `yaml:"retries,omitempty"`
+	StartPeriod string `yaml:"start_period,omitempty"`
+	Disable     bool   `yaml:"disable,omitempty"`
+}
+
+// LoggingConfig represents the logging configuration of a service.
+type LoggingConfig struct {
+	Driver  string  `yaml:"driver,omitempty"`
+	Options Mapping `yaml:"options,omitempty"`
+}
+
+// ServiceNetworkConfig represents the configuration of a service in a network.
+type ServiceNetworkConfig struct {
+	Ipv4Address  string   `yaml:"ipv4_address,omitempty"`
+	Ipv6Address  string   `yaml:"ipv6_address,omitempty"`
+	Aliases      []string `yaml:"aliases,omitempty"`
+	LinkLocalIPs []string `yaml:"link_local_ips,omitempty"`
+}
+
+// NetworkConfig represents a top-level network of a docker compose file.
+type NetworkConfig struct {
+	// Name is the name of the network under the networks key.
+	Name string `yaml:"-"`
+
+	// NetworkName is the name of the network in the docker engine. Docker compose
+	// prefixes the network with the project name when it is empty.
+	NetworkName string      `yaml:"name,omitempty"`
+	Driver      string      `yaml:"driver,omitempty"`
+	DriverOpts  Mapping     `yaml:"driver_opts,omitempty"`
+	Attachable  bool        `yaml:"attachable,omitempty"`
+	EnableIPv6  bool        `yaml:"enable_ipv6,omitempty"`
+	External    bool        `yaml:"external,omitempty"`
+	Internal    bool        `yaml:"internal,omitempty"`
+	Ipam        *IPAMConfig `yaml:"ipam,omitempty"`
+	Labels      Mapping     `yaml:"labels,omitempty"`
+}
+
+// IPAMConfig represents the IP address management of a network.
+type IPAMConfig struct {
+	Driver  string      `yaml:"driver,omitempty"`
+	Config  []*IPAMPool `yaml:"config,omitempty"`
+	Options Mapping     `yaml:"options,omitempty"`
+}
+
+// IPAMPool represents an address pool of a network.
+type IPAMPool struct {
+	Subnet       string  `yaml:"subnet,omitempty"`
+	IPRange      string  `yaml:"ip_range,omitempty"`
+	Gateway      string  `yaml:"gateway,omitempty"`
+	AuxAddresses Mapping `yaml:"aux_addresses,omitempty"`
+}
+
+// VolumeConfig represents a top-level volume of a docker compose file.
+type VolumeConfig struct {
+	// Name is the name of the volume under the volumes key.
+	Name string `yaml:"-"`
+
+	// VolumeName is the name of the volume in the docker engine. Docker compose
+	// prefixes the volume with the project name when it is empty.
+	VolumeName string  `yaml:"name,omitempty"`
+	Driver     string  `yaml:"driver,omitempty"`
+	DriverOpts Mapping `yaml:"driver_opts,omitempty"`
+	External   bool    `yaml:"external,omitempty"`
+	Labels     Mapping `yaml:"labels,omitempty"`
+}
+
+// ServiceVolume represents a volume mounted into a service.
+// Spec holds the short syntax SOURCE:TARGET[:MODE] and Mount the long syntax.
+type ServiceVolume struct {
+	Spec  string
+	Mount *Mount
+}
+
+// ServicePort represents a port published by a service.
+// Spec holds the short syntax [HOST:]CONTAINER[/PROTOCOL] and Port the long syntax,
+// used for ports which cannot be written in the short syntax.
+type ServicePort struct {
+	Spec string
+	Port *PortConfig
+}
+
+// PortConfig is the long syntax of a published port.
+type PortConfig struct {
+	Target    string `yaml:"target,omitempty"`
+	Published string `yaml:"published,omitempty"`
+	Protocol  string `yaml:"protocol,omitempty"`
+	Mode      string `yaml:"mode,omitempty"`
+}
+
+// String returns the port in the short syntax. The publish mode of the long syntax is left out.
+func (p ServicePort) String() string {
+	if p.Port == nil {
+		return p.Spec
+	}
+	port := p.Port.Target
+	if p.Port.Published != "" {
+		port = p.Port.Published + ":" + port
+	}
+	if p.Port.Protocol != "" && p.Port.Protocol != "tcp" {
+		port += "/" + p.Port.Protocol
+	}
+	return port
+}
+
+// KeyValue is an entry of a Mapping.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Mapping is a list of key value pairs which keeps the insertion order of the keys.
+// A key with an empty value is rendered without a value, eg: environment variables
+// which are taken from the host environment.
+type Mapping []KeyValue
+
+// Get returns the value of key and whether the key is present.
+func (m Mapping) Get(key string) (string, bool) {
+	for _, kv := range m {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return "", false
+}
+
+// Set sets the value of key, adding the key if it is not present.
+func (m *Mapping) Set(key, value string) {
+	for i, kv := range *m {
+		if kv.Key == key {
+			(*m)[i].Value = value
+			return
+		}
+	}
+	*m = append(*m, KeyValue{Key: key, Value: value})
+}
+
+// Delete removes key from the mapping.
+func (m *Mapping) Delete(key string) {
+	for i, kv := range *m {
+		if kv.Key == key {
+			*m = append((*m)[:i], (*m)[i+1:]...)
+			return
+		}
+	}
+}
+
+// Keys returns the keys of the mapping in order.
+func (m Mapping) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for _, kv := range m {
+		keys = append(keys, kv.Key)
+	}
+	return keys
+}
+
+var (
+	mappingType        = reflect.TypeOf(Mapping{})
+	ulimitsType        = reflect.TypeOf([]*Ulimit{})
+	serviceVolumeType  = reflect.TypeOf([]ServiceVolume{})
+	servicePortType    = reflect.TypeOf([]ServicePort{})
+	serviceNetworkType = reflect.TypeOf(map[string]*ServiceNetworkConfig{})
+	preferencesType    = reflect.TypeOf([]PlacementPreference{})
+)
+
+// record marks the attribute path and its parents as set.
+func (s *ServiceConfig) record(path string) {
+	if s.order == nil {
+		s.order = make(map[string]int)
+	}
+	segments := strings.Split(path, ".")
+	for i := range segments {
+		p := strings.Join(segments[:i+1], ".")
+		if _, ok := s.order[p]; !ok {
+			s.order[p] = len(s.order)
+		}
+	}
+}
+
+// SetNetworks connects the service to the given networks instead of its network mode.
+// The networks are rendered at the position of the network mode.
+func (s *ServiceConfig) SetNetworks(networks map[string]*ServiceNetworkConfig) {
+	if pos, ok := s.order["network_mode"]; ok {
+		if current, ok := s.order["networks"]; !ok || pos < current {
+			s.order["networks"] = pos
+		}
+	}
+	s.NetworkMode = ""
+	s.Networks = networks
+}
+
+// setFlag sets the attribute at the dotted compose path to the value of a docker run flag.
+// A trailing "$var" segment or a list attribute appends the value instead of replacing it.
+func (s *ServiceConfig) setFlag(composePath string, ftype FlagType, value string) error {
+	value = trimQuotes(value)
+
+	var keys []string
+	for _, key := range strings.Split(composePath, ".") {
+		if key != "$var" {
+			keys = append(keys, key)
+		}
+	}
+
+	field := reflect.ValueOf(s).Elem()
+	for _, key := range keys {
+		switch field.Kind() {
+		case reflect.Struct:
+			f, ok := fieldByTag(field, key)
+			if !ok {
+				return fmt.Errorf("unknown docker compose attribute %q", composePath)
+			}
+			field = f
+		case reflect.Map:
+			if field.IsNil() {
+				field.Set(reflect.MakeMap(field.Type()))
+			}
+			elem := field.MapIndex(reflect.ValueOf(key))
+			if !elem.IsValid() {
+				elem = reflect.New(field.Type().Elem().Elem())
+				field.SetMapIndex(reflect.ValueOf(key), elem)
+			}
+			field = elem.Elem()
+		default:
+			return fmt.Errorf("unknown docker compose attribute %q", composePath)
+		}
+
+		if field.Kind() == reflect.Ptr && field.Type().Elem().Kind() == reflect.Struct {
+			if field.IsNil() {
+				field.Set(reflect.New(field.Type().Elem()))
+			}
+			field = field.Elem()
+		}
+	}
+
+	if err := setValue(field, ftype, value); err != nil {
+		return err
+	}
+
+	s.record(strings.Join(keys, "."))
+	return nil
+}
+
+// setValue converts the flag value to the type of field and sets or appends it.
+func setValue(field reflect.Value, ftype FlagType, value string) error {
+	switch field.Type() {
+	case mappingType:
+		k, v, _ := strings.Cut(value, "=")
+		field.Addr().Interface().(*Mapping).Set(k, trimQuotes(v))
+		return nil
+	case ulimitsType:
+		ulimit, err := ParseUlimit(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, reflect.ValueOf(ulimit)))
+		return nil
+	case serviceVolumeType:
+		volume := ServiceVolume{Spec: value}
+		if ftype == MountType {
+			mount, err := ParseMount(value)
+			if err != nil {
+				return err
+			}
+			volume = ServiceVolume{Mount: mount}
+		}
+		field.Set(reflect.Append(field, reflect.ValueOf(volume)))
+		return nil
+	case servicePortType:
+		port := ServicePort{Spec: value}
+		if ftype == PortType {
+			var err error
+			if port, err = ParsePort(value); err != nil {
+				return err
+			}
+		}
+		field.Set(reflect.Append(field, reflect.ValueOf(port)))
+		return nil
+	case serviceNetworkType:
+		name, network, err := parseServiceNetwork(value)
+		if err != nil {
+			return err
+		}
+		if field.IsNil() {
+			field.Set(reflect.MakeMap(serviceNetworkType))
+		}
+		field.SetMapIndex(reflect.ValueOf(name), reflect.ValueOf(network))
+		return nil
+	case preferencesType:
+		preference, err := parsePlacementPreference(value)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.Append(field, reflect.ValueOf(preference)))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(field.Type().Elem())
+		if err := setValue(elem.Elem(), ftype, value); err != nil {
+			return err
+		}
+		field.Set(elem)
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(v)
+	case reflect.Int64:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(v)
+	case reflect.Float64:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(v)
+	case reflect.Slice:
+		field.Set(reflect.Append(field, reflect.ValueOf(value)))
+	default:
+		return fmt.Errorf("unsupported attribute type %s", field.Type())
+	}
+	return nil
+}
+
+// fieldByTag returns the struct field whose yaml tag name is key.
+func fieldByTag(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if yamlName(t.Field(i)) == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// yamlName returns the attribute name of a struct field from its yaml tag.
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	return name
+}
@@ -0,0 +1,328 @@
+package parser
+
+// serviceKey is the compose name of the flag which names the service itself.
+const serviceKey = "^services.$service"
+
+// newServiceVariables returns the variables of the docker service create flags.
+func newServiceVariables() *variables {
+	vars := &variables{}
+
+	// map docker service create flags to the docker compose file flags used by docker stack deploy
+	// Defined according to the specification here: https://github.com/compose-spec/compose-spec/blob/master/deploy.md
+	vars.vars = map[string]DockerFlag{
+		"cap-add": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.cap_add.$var",
+		},
+		"cap-drop": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.cap_drop.$var",
+		},
+		"config": { // TODO: requires top-level configs
+			Type: StringType,
+			Todo: true,
+		},
+		"constraint": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.deploy.placement.constraints.$var",
+		},
+		"container-label": {
+			Type:        MapType,
+			ComposeName: "^services.$service.labels.$var",
+		},
+		"credential-spec": { // TODO: to be supported
+			Type: StringType,
+			Todo: true,
+		},
+		"d": {
+			Reference: "detach",
+		},
+		"detach": {
+			Type:  BoolType,
+			Alias: "d",
+		},
+		"dns": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.dns.$var",
+		},
+		"dns-option": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.dns_opt.$var",
+		},
+		"dns-search": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.dns_search.$var",
+		},
+		"e": {
+			Reference: "env",
+		},
+		"endpoint-mode": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.endpoint_mode",
+		},
+		"entrypoint": {
+			Type:        StringType,
+			ComposeName: "^services.$service.entrypoint",
+		},
+		"env": {
+			Type:        MapType,
+			ComposeName: "^services.$service.environment.$var",
+			Alias:       "e",
+		},
+		"env-file": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.env_file.$var",
+		},
+		"generic-resource": { // TODO: to be supported
+			Type: ArrayType,
+			// ComposeName: "^services.$service.deploy.resources.reservations.generic_resources",
+			Todo: true,
+		},
+		"group": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.group_add.$var",
+		},
+		"health-cmd": {
+			Type:        StringType,
+			ComposeName: "^services.$service.healthcheck.test",
+		},
+		"health-interval": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.healthcheck.interval",
+		},
+		"health-retries": {
+			Type:        IntType,
+			ComposeName: "^services.$service.healthcheck.retries",
+		},
+		"health-start-period": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.healthcheck.start_period",
+		},
+		"health-timeout": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.healthcheck.timeout",
+		},
+		"host": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.extra_hosts.$var",
+		},
+		"hostname": {
+			Type:        StringType,
+			ComposeName: "^services.$service.hostname",
+		},
+		"init": {
+			Type:        BoolType,
+			ComposeName: "^services.$service.init",
+		},
+		"isolation": {
+			Type:        StringType,
+			ComposeName: "^services.$service.isolation",
+		},
+		"l": {
+			Reference: "label",
+		},
+		"label": {
+			Type:        MapType,
+			ComposeName: "^services.$service.deploy.labels.$var",
+			Alias:       "l",
+		},
+		"limit-cpu": {
+			Type:        Float64Type,
+			ComposeName: "^services.$service.deploy.resources.limits.cpus",
+		},
+		"limit-memory": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.resources.limits.memory",
+		},
+		"limit-pids": {
+			Type:        IntType,
+			ComposeName: "^services.$service.deploy.resources.limits.pids",
+		},
+		"log-driver": {
+			Type:        StringType,
+			ComposeName: "^services.$service.logging.driver",
+		},
+		"log-opt": {
+			Type:        MapType,
+			ComposeName: "^services.$service.logging.options.$var",
+		},
+		"max-concurrent": { // TODO: not supported in compose?
+			Type: IntType,
+		},
+		"mode": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.mode",
+		},
+		"mount": {
+			Type:        MountType,
+			ComposeName: "^services.$service.volumes.$var",
+		},
+		"name": {
+			Type:        StringType,
+			ComposeName: serviceKey,
+		},
+		"network": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.networks.$var",
+		},
+		"no-healthcheck": {
+			Type:        BoolType,
+			ComposeName: "^services.$service.healthcheck.disable",
+		},
+		"no-resolve-image": {
+			Type: BoolType,
+		},
+		"p": {
+			Reference: "publish",
+		},
+		"placement-pref": {
+			Type:        ArrayType,
+			ComposeName: "^services.$service.deploy.placement.preferences.$var",
+		},
+		"publish": {
+			Type:        PortType,
+			ComposeName: "^services.$service.ports.$var",
+			Alias:       "p",
+		},
+		"q": {
+			Reference: "quiet",
+		},
+		"quiet": {
+			Type:  BoolType,
+			Alias: "q",
+		},
+		"read-only": {
+			Type:        BoolType,
+			ComposeName: "^services.$service.read_only",
+		},
+		"replicas": {
+			Type:        IntType,
+			ComposeName: "^services.$service.deploy.replicas",
+		},
+		"replicas-max-per-node": {
+			Type:        IntType,
+			ComposeName: "^services.$service.deploy.placement.max_replicas_per_node",
+		},
+		"reserve-cpu": {
+			Type:        Float64Type,
+			ComposeName: "^services.$service.deploy.resources.reservations.cpus",
+		},
+		"reserve-memory": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.resources.reservations.memory",
+		},
+		"restart-condition": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.restart_policy.condition",
+		},
+		"restart-delay": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.deploy.restart_policy.delay",
+		},
+		"restart-max-attempts": {
+			Type:        IntType,
+			ComposeName: "^services.$service.deploy.restart_policy.max_attempts",
+		},
+		"restart-window": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.deploy.restart_policy.window",
+		},
+		"rollback-delay": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.deploy.rollback_config.delay",
+		},
+		"rollback-failure-action": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.rollback_config.failure_action",
+		},
+		"rollback-max-failure-ratio": {
+			Type:        Float64Type,
+			ComposeName: "^services.$service.deploy.rollback_config.max_failure_ratio",
+		},
+		"rollback-monitor": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.deploy.rollback_config.monitor",
+		},
+		"rollback-order": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.rollback_config.order",
+		},
+		"rollback-parallelism": {
+			Type:        IntType,
+			ComposeName: "^services.$service.deploy.rollback_config.parallelism",
+		},
+		"secret": { // TODO: requires top-level secrets
+			Type: StringType,
+			Todo: true,
+		},
+		"stop-grace-period": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.stop_grace_period",
+		},
+		"stop-signal": {
+			Type:        StringType,
+			ComposeName: "^services.$service.stop_signal",
+		},
+		"sysctl": {
+			Type:        MapType,
+			ComposeName: "^services.$service.sysctls.$var",
+		},
+		"t": {
+			Reference: "tty",
+		},
+		"tty": {
+			Type:        BoolType,
+			ComposeName: "^services.$service.tty",
+			Alias:       "t",
+		},
+		"u": {
+			Reference: "user",
+		},
+		"ulimit": {
+			Type:        UlimitType,
+			ComposeName: "^services.$service.ulimits.$var",
+		},
+		"update-delay": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.deploy.update_config.delay",
+		},
+		"update-failure-action": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.update_config.failure_action",
+		},
+		"update-max-failure-ratio": {
+			Type:        Float64Type,
+			ComposeName: "^services.$service.deploy.update_config.max_failure_ratio",
+		},
+		"update-monitor": {
+			Type:        DurationType,
+			ComposeName: "^services.$service.deploy.update_config.monitor",
+		},
+		"update-order": {
+			Type:        StringType,
+			ComposeName: "^services.$service.deploy.update_config.order",
+		},
+		"update-parallelism": {
+			Type:        IntType,
+			ComposeName: "^services.$service.deploy.update_config.parallelism",
+		},
+		"user": {
+			Type:        StringType,
+			ComposeName: "^services.$service.user",
+			Alias:       "u",
+		},
+		"w": {
+			Reference: "workdir",
+		},
+		"with-registry-auth": {
+			Type: BoolType,
+		},
+		"workdir": {
+			Type:        StringType,
+			ComposeName: "^services.$service.working_dir",
+			Alias:       "w",
+		},
+	}
+
+	return vars
+}
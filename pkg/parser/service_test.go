@@ -0,0 +1,111 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceConfig(t *testing.T) {
+	p, err := New("docker run -d --name web -p 8080:80 -e FOO=1 -e BAR --cpus 0.5 --memory-swappiness 0 --health-interval 5s --health-cmd 'curl -f localhost' nginx:1.25 nginx -g 'daemon off;'")
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+
+	service := p.Service()
+	require.Same(t, service, p.Project().Service("nginx"))
+	require.Equal(t, "nginx:1.25", service.Image)
+	require.Equal(t, "web", service.ContainerName)
+	require.Equal(t, []ServicePort{{Spec: "8080:80"}}, service.Ports)
+	require.Equal(t, Mapping{{Key: "FOO", Value: "1"}, {Key: "BAR"}}, service.Environment)
+	require.Equal(t, 0.5, service.Deploy.Resources.Limits.CPUs)
+	require.Equal(t, int64(0), *service.MemSwappiness)
+	require.Equal(t, "curl -f localhost", service.Healthcheck.Test)
+	require.Equal(t, []string{"nginx", "-g", "daemon off;"}, service.Command)
+
+	service.Environment.Set("FOO", "2")
+	service.Environment.Delete("BAR")
+	service.Restart = "always"
+	service.Ports = append(service.Ports, ServicePort{Spec: "8443:443"})
+
+	b, err := p.Render()
+	require.NoError(t, err)
+	require.Equal(t, `version: "3.8"
+services:
+    nginx:
+        container_name: web
+        ports:
+            - 8080:80
+            - 8443:443
+        environment:
+            FOO: 2
+        deploy:
+            resources:
+                limits:
+                    cpus: 0.5
+        mem_swappiness: 0
+        healthcheck:
+            interval: 5s
+            test: curl -f localhost
+        image: nginx:1.25
+        command:
+            - nginx
+            - -g
+            - daemon off;
+        restart: always
+`, string(b))
+	require.Equal(t, string(b), p.String())
+}
+
+func TestServiceConfigInvalidValue(t *testing.T) {
+	p, err := New("docker run --cpus many alpine")
+	require.NoError(t, err)
+	require.ErrorContains(t, p.Parse(), `invalid value many for docker run flag "cpus"`)
+}
+
+func TestProjectNetworksAndVolumes(t *testing.T) {
+	p, err := New("docker run --network backend -v data:/data redis", WithTarget(""))
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+
+	project := p.Project()
+	project.Networks = append(project.Networks, &NetworkConfig{
+		Name:        "backend",
+		NetworkName: "backend",
+		Internal:    true,
+		Ipam: &IPAMConfig{Config: []*IPAMPool{
+			{Subnet: "172.28.0.0/16", Gateway: "172.28.0.1"},
+		}},
+	})
+	project.Volumes = append(project.Volumes, &VolumeConfig{Name: "data", External: true}, &VolumeConfig{Name: "cache"})
+	p.Service().Comment = "README.md:3"
+	p.Service().SetNetworks(map[string]*ServiceNetworkConfig{"backend": {Aliases: []string{"cache"}}})
+	require.Same(t, project.Networks[0], project.Network("backend"))
+	require.Same(t, project.Volumes[1], project.Volume("cache"))
+	require.Nil(t, project.Volume("logs"))
+
+	b, err := p.Render()
+	require.NoError(t, err)
+	require.Equal(t, `services:
+    # README.md:3
+    redis:
+        networks:
+            backend:
+                aliases:
+                    - cache
+        volumes:
+            - data:/data
+        image: redis
+networks:
+    backend:
+        name: backend
+        internal: true
+        ipam:
+            config:
+                - subnet: 172.28.0.0/16
+                  gateway: 172.28.0.1
+volumes:
+    data:
+        external: true
+    cache: {}
+`, string(b))
+}
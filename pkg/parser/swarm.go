@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ParsePort converts a published port of docker service create into a port of docker compose.
+// The long format published=8080,target=80,protocol=udp becomes the short syntax 8080:80/udp,
+// ports in the short format are returned unchanged. Ports published in host mode are kept in the
+// long syntax, as the short syntax is published through the ingress routing mesh.
+func ParsePort(s string) (ServicePort, error) {
+	if s == "" {
+		return ServicePort{}, errInvalidFlag
+	}
+	if !strings.Contains(s, "=") {
+		return ServicePort{Spec: s}, nil
+	}
+
+	port := &PortConfig{}
+	for _, field := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "published":
+			port.Published = value
+		case "target":
+			port.Target = value
+		case "protocol":
+			port.Protocol = value
+		case "mode":
+			if value != "ingress" && value != "host" {
+				return ServicePort{}, fmt.Errorf("unknown publish mode %q", value)
+			}
+			port.Mode = value
+		default:
+			return ServicePort{}, fmt.Errorf("unknown publish option %q", key)
+		}
+	}
+	if port.Target == "" {
+		return ServicePort{}, fmt.Errorf("missing target port in %q", s)
+	}
+
+	if port.Mode == "host" {
+		return ServicePort{Port: port}, nil
+	}
+	return ServicePort{Spec: ServicePort{Port: port}.String()}, nil
+}
+
+// parseServiceNetwork parses a network of docker service create, either the name of the network
+// or the long format name=backend,alias=api.
+func parseServiceNetwork(s string) (string, *ServiceNetworkConfig, error) {
+	if s == "" {
+		return "", nil, errInvalidFlag
+	}
+	if !strings.Contains(s, "=") {
+		return s, nil, nil
+	}
+
+	var name string
+	var network *ServiceNetworkConfig
+	for _, field := range strings.Split(s, ",") {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "name":
+			name = value
+		case "alias":
+			if network == nil {
+				network = &ServiceNetworkConfig{}
+			}
+			network.Aliases = append(network.Aliases, value)
+		default:
+			return "", nil, fmt.Errorf("unsupported network option %q", key)
+		}
+	}
+	if name == "" {
+		return "", nil, fmt.Errorf("missing network name in %q", s)
+	}
+	return name, network, nil
+}
+
+// parsePlacementPreference parses a placement preference of docker service create, eg: spread=node.labels.zone.
+func parsePlacementPreference(s string) (PlacementPreference, error) {
+	strategy, label, _ := strings.Cut(s, "=")
+	if strategy != "spread" || label == "" {
+		return PlacementPreference{}, fmt.Errorf("invalid placement preference %q", s)
+	}
+	return PlacementPreference{Spread: label}, nil
+}
+
+// declareNetworks adds the networks the service is connected to which are not declared yet
+// as external top-level networks. Services created with docker service create connect to existing networks.
+func (p *Parser) declareNetworks() {
+	names := make([]string, 0, len(p.service.Networks))
+	for name := range p.service.Networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if p.project.Network(name) == nil {
+			p.project.Networks = append(p.project.Networks, &NetworkConfig{Name: name, External: true})
+		}
+	}
+}
+
+// declareVolumes adds the named volumes mounted with --mount which are not declared yet
+// as external top-level volumes. Services created with docker service create mount existing volumes.
+func (p *Parser) declareVolumes() {
+	for _, v := range p.service.Volumes {
+		m := v.Mount
+		if m == nil || m.Source == "" || (m.Type != "" && m.Type != "volume") {
+			continue
+		}
+		if p.project.Volume(m.Source) == nil {
+			p.project.Volumes = append(p.project.Volumes, &VolumeConfig{Name: m.Source, External: true})
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceCreate(t *testing.T) {
+	p, err := New("docker service create --name api --replicas 3 --constraint node.role==manager " +
+		"--placement-pref spread=node.labels.zone --update-parallelism 2 --update-delay 10s " +
+		"--rollback-parallelism 0 --rollback-order start-first --limit-cpu 0.5 --reserve-memory 256M " +
+		"--endpoint-mode dnsrr --restart-condition on-failure -l com.example.stack=shop " +
+		"--container-label tier=api --network backend --network name=front,alias=web " +
+		"-p published=8080,target=80 --with-registry-auth -d myorg/api:1.2 serve")
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+	require.Equal(t, `version: "3.8"
+services:
+    api:
+        deploy:
+            replicas: 3
+            placement:
+                constraints:
+                    - node.role==manager
+                preferences:
+                    - spread: node.labels.zone
+            update_config:
+                parallelism: 2
+                delay: 10s
+            rollback_config:
+                parallelism: 0
+                order: start-first
+            resources:
+                limits:
+                    cpus: 0.5
+                reservations:
+                    memory: 256M
+            endpoint_mode: dnsrr
+            restart_policy:
+                condition: on-failure
+            labels:
+                com.example.stack: shop
+        labels:
+            tier: api
+        networks:
+            backend: {}
+            front:
+                aliases:
+                    - web
+        ports:
+            - 8080:80
+        image: myorg/api:1.2
+        command:
+            - serve
+networks:
+    backend:
+        external: true
+    front:
+        external: true
+`, p.String())
+
+	t.Run("service name option takes precedence", func(t *testing.T) {
+		p, err := New("docker service create --name api nginx", WithServiceName("web"), WithTarget(""))
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, "services:\n    web:\n        image: nginx\n", p.String())
+	})
+
+	t.Run("ports published in host mode", func(t *testing.T) {
+		p, err := New("docker service create --mode global -p 443:443 --publish mode=host,target=80,published=8080 nginx", WithTarget(""))
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, `services:
+    nginx:
+        deploy:
+            mode: global
+        ports:
+            - 443:443
+            - target: 80
+              published: 8080
+              mode: host
+        image: nginx
+`, p.String())
+	})
+
+	t.Run("named volumes are external", func(t *testing.T) {
+		p, err := New("docker service create --mount type=volume,src=data,dst=/data --mount type=bind,src=/srv,dst=/srv "+
+			"--mount type=volume,dst=/scratch --mount src=cache,dst=/cache --mount src=data,dst=/backup nginx", WithTarget(""))
+		require.NoError(t, err)
+		require.NoError(t, p.Parse())
+		require.Equal(t, `services:
+    nginx:
+        volumes:
+            - type: volume
+              source: data
+              target: /data
+            - type: bind
+              source: /srv
+              target: /srv
+            - type: volume
+              target: /scratch
+            - source: cache
+              target: /cache
+            - source: data
+              target: /backup
+        image: nginx
+volumes:
+    data:
+        external: true
+    cache:
+        external: true
+`, p.String())
+	})
+
+	t.Run("strict mode", func(t *testing.T) {
+		p, err := New("docker service create --secret db_password nginx", WithStrict(true))
+		require.NoError(t, err)
+		require.EqualError(t, p.Parse(), `docker service create flag "secret" is not supported in docker compose`)
+
+		p, err = New("docker service create -d --quiet --rm nginx", WithStrict(true))
+		require.NoError(t, err)
+		require.EqualError(t, p.Parse(), `unknown docker service create flag "rm"`)
+	})
+}
+
+func TestParsePort(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    ServicePort
+		wantErr string
+	}{
+		{s: "8080:80", want: ServicePort{Spec: "8080:80"}},
+		{s: "published=8080,target=80", want: ServicePort{Spec: "8080:80"}},
+		{s: "target=80,protocol=udp", want: ServicePort{Spec: "80/udp"}},
+		{s: "published=53,target=53,protocol=tcp,mode=ingress", want: ServicePort{Spec: "53:53"}},
+		{
+			s:    "published=8080,target=80,mode=host",
+			want: ServicePort{Port: &PortConfig{Target: "80", Published: "8080", Mode: "host"}},
+		},
+		{
+			s:    "mode=host,target=53,protocol=udp",
+			want: ServicePort{Port: &PortConfig{Target: "53", Protocol: "udp", Mode: "host"}},
+		},
+		{s: "published=8080,target=80,mode=local", wantErr: `unknown publish mode "local"`},
+		{s: "published=8080", wantErr: `missing target port in "published=8080"`},
+		{s: "published=8080,target=80,foo=bar", wantErr: `unknown publish option "foo"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParsePort(tt.s)
+			if tt.wantErr != "" {
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
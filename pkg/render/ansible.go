@@ -0,0 +1,243 @@
+package render
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// Ansible renders a playbook task starting the container with the
+// community.docker.docker_container module. Env and label values are double quoted
+// as the module requires strings.
+func Ansible(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	containerName := s.ContainerName
+	if containerName == "" {
+		containerName = s.Name
+	}
+
+	c := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(c, "name", yamlScalar(containerName))
+	yamlSet(c, "image", yamlScalar(s.Image))
+	yamlSet(c, "state", yamlScalar("started"))
+	if s.Entrypoint != "" {
+		yamlSet(c, "entrypoint", yamlStrings([]string{s.Entrypoint}))
+	}
+	if len(s.Command) > 0 {
+		yamlSet(c, "command", yamlStrings(s.Command))
+	}
+	ansiblePorts(c, "published_ports", portSpecs(s.Ports))
+	ansiblePorts(c, "exposed_ports", s.Expose)
+	if env := definedEnv(s.Environment); len(env) > 0 {
+		yamlSet(c, "env", yamlQuotedMap(env))
+	}
+
+	var mounts []*yaml.Node
+	var tmpfs []string
+	for _, m := range serviceMounts(s) {
+		if m.Type == tmpfsMount {
+			tmpfs = append(tmpfs, strings.TrimSuffix(m.Target+":"+m.Options, ":"))
+			continue
+		}
+		mount := &yaml.Node{Kind: yaml.MappingNode}
+		if m.Source != "" {
+			yamlSet(mount, "source", yamlScalar(m.Source))
+		}
+		yamlSet(mount, "target", yamlScalar(m.Target))
+		yamlSet(mount, "type", yamlScalar(m.Type))
+		if m.ReadOnly {
+			yamlSet(mount, "read_only", ansibleBool(true))
+		}
+		mounts = append(mounts, mount)
+	}
+	if len(mounts) > 0 {
+		yamlSet(c, "mounts", &yaml.Node{Kind: yaml.SequenceNode, Content: mounts})
+	}
+	ansibleStrings(c, "tmpfs", tmpfs)
+
+	if s.Deploy != nil && s.Deploy.Resources != nil {
+		if limits := s.Deploy.Resources.Limits; limits != nil {
+			if limits.CPUs > 0 {
+				yamlSet(c, "cpus", &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: strconv.FormatFloat(limits.CPUs, 'f', -1, 64)})
+			}
+			ansibleString(c, "memory", limits.Memory)
+		}
+		if reservations := s.Deploy.Resources.Reservations; reservations != nil {
+			ansibleString(c, "memory_reservation", reservations.Memory)
+		}
+	}
+	ansibleString(c, "memory_swap", s.MemswapLimit)
+	ansibleString(c, "shm_size", s.ShmSize)
+
+	if h := s.Healthcheck; h != nil && (h.Test != "" || h.Disable) {
+		health := &yaml.Node{Kind: yaml.MappingNode}
+		if h.Disable {
+			yamlSet(health, "test", yamlFlowStrings([]string{"NONE"}))
+		} else {
+			yamlSet(health, "test", yamlFlowStrings([]string{"CMD-SHELL", h.Test}))
+			ansibleString(health, "interval", h.Interval)
+			ansibleString(health, "timeout", h.Timeout)
+			if h.Retries > 0 {
+				yamlSet(health, "retries", ansibleInt(h.Retries))
+			}
+			ansibleString(health, "start_period", h.StartPeriod)
+		}
+		yamlSet(c, "healthcheck", health)
+	}
+
+	if s.Restart != "" {
+		policy, count, _ := strings.Cut(s.Restart, ":")
+		yamlSet(c, "restart_policy", yamlScalar(policy))
+		if count != "" {
+			retries, err := strconv.ParseInt(count, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid restart policy %q", s.Restart)
+			}
+			yamlSet(c, "restart_retries", ansibleInt(retries))
+		}
+	}
+	ansibleString(c, "user", s.User)
+	ansibleString(c, "working_dir", s.WorkingDir)
+	ansibleString(c, "hostname", s.Hostname)
+	ansibleString(c, "domainname", s.Domainname)
+	ansibleStrings(c, "capabilities", s.CapAdd)
+	ansibleStrings(c, "cap_drop", s.CapDrop)
+	if len(s.Labels) > 0 {
+		yamlSet(c, "labels", yamlQuotedMap(s.Labels))
+	}
+	if s.Logging != nil {
+		ansibleString(c, "log_driver", s.Logging.Driver)
+		if len(s.Logging.Options) > 0 {
+			yamlSet(c, "log_options", s.Logging.Options.YAML())
+		}
+	}
+	if len(s.Ulimits) > 0 {
+		var ulimits []string
+		for _, u := range s.Ulimits {
+			ulimits = append(ulimits, fmt.Sprintf("%s:%d:%d", u.Name, u.Soft, u.Hard))
+		}
+		ansibleStrings(c, "ulimits", ulimits)
+	}
+
+	switch mode := s.NetworkMode; {
+	case mode == "":
+	case containsString(dockerNetworkModes, mode) || strings.HasPrefix(mode, "container:"):
+		yamlSet(c, "network_mode", yamlScalar(mode))
+	default:
+		network := &yaml.Node{Kind: yaml.MappingNode}
+		yamlSet(network, "name", yamlScalar(mode))
+		if n := s.Networks["default"]; n != nil {
+			ansibleStrings(network, "aliases", n.Aliases)
+			ansibleString(network, "ipv4_address", n.Ipv4Address)
+			ansibleString(network, "ipv6_address", n.Ipv6Address)
+		}
+		yamlSet(c, "networks", &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{network}})
+	}
+
+	for _, f := range []struct {
+		name  string
+		value bool
+	}{
+		{"privileged", s.Privileged},
+		{"read_only", s.ReadOnly},
+		{"init", s.Init},
+		{"interactive", s.StdinOpen},
+		{"tty", s.Tty},
+	} {
+		if f.value {
+			yamlSet(c, f.name, ansibleBool(true))
+		}
+	}
+	for _, f := range p.Flags() {
+		if f.Name == "rm" && f.Value == "true" {
+			yamlSet(c, "auto_remove", ansibleBool(true))
+		}
+	}
+
+	ansibleStrings(c, "dns_servers", s.DNS)
+	ansibleStrings(c, "dns_search_domains", s.DNSSearch)
+	ansibleStrings(c, "dns_opts", s.DNSOpt)
+	if len(s.ExtraHosts) > 0 {
+		hosts := parser.Mapping{}
+		for _, h := range s.ExtraHosts {
+			hostname, ip, _ := strings.Cut(h, ":")
+			hosts.Set(hostname, ip)
+		}
+		yamlSet(c, "etc_hosts", hosts.YAML())
+	}
+	ansibleStrings(c, "devices", s.Devices)
+	ansibleStrings(c, "security_opts", s.SecurityOpt)
+	ansibleStrings(c, "groups", s.GroupAdd)
+	ansibleStrings(c, "links", s.Links)
+	if len(s.Sysctls) > 0 {
+		yamlSet(c, "sysctls", s.Sysctls.YAML())
+	}
+	ansibleString(c, "stop_signal", s.StopSignal)
+	if timeout, ok, err := stopTimeout(s); err != nil {
+		return nil, err
+	} else if ok {
+		yamlSet(c, "stop_timeout", ansibleInt(int64(timeout.Seconds())))
+	}
+
+	task := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(task, "name", yamlScalar("Start "+s.Name+" container"))
+	yamlSet(task, "community.docker.docker_container", c)
+	doc := &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{task}}
+	ansibleQuote(doc)
+
+	b, err := encodeYAML(doc)
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: dnsName(s.Name) + ".ansible.yml", Data: b}}, nil
+}
+
+func ansibleString(mapping *yaml.Node, key, value string) {
+	if value != "" {
+		yamlSet(mapping, key, yamlScalar(value))
+	}
+}
+
+func ansibleStrings(mapping *yaml.Node, key string, values []string) {
+	if len(values) > 0 {
+		yamlSet(mapping, key, yamlStrings(values))
+	}
+}
+
+// ansiblePorts adds the ports as double quoted strings, which are never read as numbers.
+func ansiblePorts(mapping *yaml.Node, key string, ports []string) {
+	if len(ports) > 0 {
+		seq := yamlStrings(ports)
+		for _, item := range seq.Content {
+			item.Style = yaml.DoubleQuotedStyle
+		}
+		yamlSet(mapping, key, seq)
+	}
+}
+
+// sexagesimal matches the base 60 numbers of YAML 1.1, eg: 2222:22.
+var sexagesimal = regexp.MustCompile(`^[-+]?[0-9][0-9_]*(:[0-5]?[0-9])+(\.[0-9_]*)?$`)
+
+// ansibleQuote double quotes the strings which ansible would read as numbers,
+// as it loads YAML 1.1 where 22:22 is the number 1342.
+func ansibleQuote(node *yaml.Node) {
+	if node.Kind == yaml.ScalarNode && (node.Tag == "" || node.Tag == "!!str") && node.Style == 0 && sexagesimal.MatchString(node.Value) {
+		node.Style = yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		ansibleQuote(child)
+	}
+}
+
+func ansibleBool(value bool) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(value)}
+}
+
+func ansibleInt(value int64) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.FormatInt(value, 10)}
+}
@@ -0,0 +1,68 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnsible(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{
+			name:    "ports are double quoted",
+			command: "docker run -p 2222:22 -p 22:22 -p 53:53/udp --expose 25 nginx",
+			want: []string{`
+    published_ports:
+      - "2222:22"
+      - "22:22"
+      - "53:53/udp"
+    exposed_ports:
+      - "25"
+`},
+		},
+		{
+			name:    "base 60 numbers are double quoted",
+			command: "docker run -e TIME=12:30 -l at=1:05 -u 0:0 nginx",
+			want: []string{`
+    env:
+      TIME: "12:30"
+`, `
+    user: "0:0"
+`, `
+    labels:
+      at: "1:05"
+`},
+		},
+		{
+			name:    "restart on failure with retries",
+			command: "docker run --restart on-failure:5 nginx",
+			want: []string{`
+    restart_policy: on-failure
+    restart_retries: 5
+`},
+		},
+		{
+			name:    "anonymous volume",
+			command: "docker run -v /data nginx",
+			want: []string{`
+    mounts:
+      - target: /data
+        type: volume
+`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "ansible", tt.command)
+			task := files["nginx.ansible.yml"]
+			for _, want := range tt.want {
+				require.Contains(t, task, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package render
+
+import (
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// githubNativeFlags are the docker run flags with a field in GitHub Actions service containers.
+var githubNativeFlags = map[string]bool{
+	"env":     true,
+	"publish": true,
+	"volume":  true,
+}
+
+// ciDroppedFlags are docker run flags which conflict with the container setup of the CI runner.
+// The runner names the containers and connects them to the job network.
+var ciDroppedFlags = map[string]bool{
+	"detach":      true,
+	"rm":          true,
+	"name":        true,
+	"network":     true,
+	"interactive": true,
+	"tty":         true,
+}
+
+// GitHubActions renders a GitHub Actions service container under jobs.<id>.services.<name>.
+// Flags without a service container field are passed with options.
+// Env values are double quoted to keep their exact strings.
+func GitHubActions(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+
+	service := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(service, "image", yamlScalar(s.Image))
+	if len(s.Ports) > 0 {
+		yamlSet(service, "ports", yamlStrings(portSpecs(s.Ports)))
+	}
+	if env := definedEnv(s.Environment); len(env) > 0 {
+		yamlSet(service, "env", yamlQuotedMap(env))
+	}
+	var volumes []string
+	for _, v := range s.Volumes {
+		if v.Mount == nil {
+			volumes = append(volumes, v.Spec)
+		}
+	}
+	if len(volumes) > 0 {
+		yamlSet(service, "volumes", yamlStrings(volumes))
+	}
+
+	var options []string
+	for _, f := range p.Flags() {
+		if !f.Known() || githubNativeFlags[f.Name] || ciDroppedFlags[f.Name] {
+			continue
+		}
+		if f.Type == parser.BoolType && f.Value == "true" {
+			options = append(options, "--"+f.Name)
+			continue
+		}
+		options = append(options, "--"+f.Name+"="+ciQuote(f.Value))
+	}
+	if len(options) > 0 {
+		yamlSet(service, "options", yamlScalar(strings.Join(options, " ")))
+	}
+	if len(s.Command) > 0 {
+		command := make([]string, len(s.Command))
+		for i, arg := range s.Command {
+			command[i] = ciQuote(arg)
+		}
+		service.FootComment = "command is not supported by service containers: " + strings.Join(command, " ")
+	}
+
+	services := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(services, s.Name, service)
+	job := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(job, "services", services)
+	jobs := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(jobs, "test", job)
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(doc, "jobs", jobs)
+
+	b, err := encodeYAML(doc)
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: "github-actions.yml", Data: b}}, nil
+}
+
+// GitLabCI renders a GitLab CI services entry. GitLab services have no fields for
+// most docker run flags, the dropped flags are listed in a comment.
+// Variable values are double quoted as GitLab only accepts strings and integers.
+func GitLabCI(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+
+	service := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(service, "name", yamlScalar(s.Image))
+	alias := s.Name
+	if n := s.Networks["default"]; n != nil && len(n.Aliases) > 0 {
+		alias = strings.Join(append([]string{alias}, n.Aliases...), ",")
+	}
+	yamlSet(service, "alias", yamlScalar(alias))
+	if s.Entrypoint != "" {
+		yamlSet(service, "entrypoint", yamlFlowStrings([]string{s.Entrypoint}))
+	}
+	if len(s.Command) > 0 {
+		yamlSet(service, "command", yamlFlowStrings(s.Command))
+	}
+	if env := definedEnv(s.Environment); len(env) > 0 {
+		yamlSet(service, "variables", yamlQuotedMap(env))
+	}
+
+	var dropped []string
+	for _, f := range p.Flags() {
+		switch f.Name {
+		case "env", "entrypoint", "network-alias":
+			continue
+		}
+		if f.Known() && !ciDroppedFlags[f.Name] {
+			dropped = append(dropped, f.Arg())
+		}
+	}
+	if len(dropped) > 0 {
+		service.FootComment = "flags not supported by GitLab CI services:\n" + strings.Join(dropped, "\n")
+	}
+
+	doc := &yaml.Node{Kind: yaml.MappingNode}
+	yamlSet(doc, "services", &yaml.Node{Kind: yaml.SequenceNode, Content: []*yaml.Node{service}})
+
+	b, err := encodeYAML(doc)
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: "gitlab-ci.yml", Data: b}}, nil
+}
+
+// ciQuote double quotes a docker option value containing whitespace or quotes,
+// as the GitHub Actions runner splits options like a command line.
+func ciQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func yamlSet(mapping *yaml.Node, key string, value *yaml.Node) {
+	mapping.Content = append(mapping.Content, yamlScalar(key), value)
+}
+
+func yamlScalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
+
+func yamlStrings(values []string) *yaml.Node {
+	seq := &yaml.Node{Kind: yaml.SequenceNode}
+	for _, v := range values {
+		seq.Content = append(seq.Content, yamlScalar(v))
+	}
+	return seq
+}
+
+// yamlQuotedMap converts a Mapping into a mapping of double quoted strings,
+// for tools which reject values read as numbers or booleans.
+func yamlQuotedMap(m parser.Mapping) *yaml.Node {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for _, kv := range m {
+		value := yamlScalar(kv.Value)
+		value.Style = yaml.DoubleQuotedStyle
+		yamlSet(mapping, kv.Key, value)
+	}
+	return mapping
+}
+
+func yamlFlowStrings(values []string) *yaml.Node {
+	seq := yamlStrings(values)
+	seq.Style = yaml.FlowStyle
+	return seq
+}
@@ -0,0 +1,109 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHubActions(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "runner flags are dropped",
+			command: "docker run -d -it --rm --name db --network ci -p 5432:5432 postgres:16",
+			want: []string{`
+      postgres:
+        image: postgres:16
+        ports:
+          - 5432:5432
+`},
+			notWant: []string{"options", "--detach", "--rm", "--name", "--network"},
+		},
+		{
+			name:    "options are quoted",
+			command: "docker run --health-cmd 'pg_isready -U postgres' --health-interval 10s --read-only postgres:16",
+			want:    []string{`options: --health-cmd="pg_isready -U postgres" --health-interval=10s --read-only` + "\n"},
+		},
+		{
+			name:    "host environment is left out",
+			command: "docker run -e POSTGRES_PASSWORD=pw -e FOO postgres:16",
+			want:    []string{"        env:\n          POSTGRES_PASSWORD: \"pw\"\n"},
+			notWant: []string{"FOO"},
+		},
+		{
+			name:    "volumes and mounts",
+			command: "docker run -v data:/var/lib -v /anon --mount type=tmpfs,dst=/run postgres:16",
+			want: []string{
+				"        volumes:\n          - data:/var/lib\n          - /anon\n",
+				"options: --mount=type=tmpfs,dst=/run\n",
+			},
+		},
+		{
+			name:    "command is a comment",
+			command: "docker run postgres:16 postgres -c 'shared_buffers=256MB' -c 'log_line_prefix=%t %p'",
+			want:    []string{`# command is not supported by service containers: postgres -c shared_buffers=256MB -c "log_line_prefix=%t %p"`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workflow := renderFiles(t, "github-actions", tt.command)["github-actions.yml"]
+			for _, want := range tt.want {
+				require.Contains(t, workflow, want)
+			}
+			for _, notWant := range tt.notWant {
+				require.NotContains(t, workflow, notWant)
+			}
+		})
+	}
+}
+
+func TestGitLabCI(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "network aliases are service aliases",
+			command: "docker run --network-alias cache --network-alias store redis",
+			want:    []string{"    alias: redis,cache,store\n"},
+			notWant: []string{"flags not supported"},
+		},
+		{
+			name:    "entrypoint and command",
+			command: "docker run --entrypoint /bin/sh redis -c 'redis-server --port 6380'",
+			want:    []string{"    entrypoint: [/bin/sh]\n    command: [-c, redis-server --port 6380]\n"},
+		},
+		{
+			name:    "host environment is left out",
+			command: "docker run -e MODE=test -e FOO redis",
+			want:    []string{"    variables:\n      MODE: \"test\"\n"},
+			notWant: []string{"FOO"},
+		},
+		{
+			name:    "unsupported flags are listed",
+			command: "docker run -d --rm --name cache -p 6379:6379 --tmpfs /data redis",
+			want:    []string{"# flags not supported by GitLab CI services:\n# --publish=6379:6379\n# --tmpfs=/data\n"},
+			notWant: []string{"--detach", "--rm", "--name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := renderFiles(t, "gitlab-ci", tt.command)["gitlab-ci.yml"]
+			for _, want := range tt.want {
+				require.Contains(t, config, want)
+			}
+			for _, notWant := range tt.notWant {
+				require.NotContains(t, config, notWant)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// devContainer is a dev container configuration, see https://containers.dev/implementors/json_reference/
+type devContainer struct {
+	Name            string            `json:"name"`
+	Image           string            `json:"image"`
+	ForwardPorts    []int             `json:"forwardPorts,omitempty"`
+	ContainerEnv    map[string]string `json:"containerEnv,omitempty"`
+	Mounts          []string          `json:"mounts,omitempty"`
+	RemoteUser      string            `json:"remoteUser,omitempty"`
+	WorkspaceFolder string            `json:"workspaceFolder,omitempty"`
+	Privileged      bool              `json:"privileged,omitempty"`
+	Init            bool              `json:"init,omitempty"`
+	CapAdd          []string          `json:"capAdd,omitempty"`
+	SecurityOpt     []string          `json:"securityOpt,omitempty"`
+	RunArgs         []string          `json:"runArgs,omitempty"`
+}
+
+// devContainerFlags are the docker run flags with a dev container property, or which
+// conflict with the container the dev container tools create, and are not passed with runArgs.
+var devContainerFlags = map[string]bool{
+	"publish":      true,
+	"env":          true,
+	"volume":       true,
+	"mount":        true,
+	"user":         true,
+	"workdir":      true,
+	"privileged":   true,
+	"init":         true,
+	"cap-add":      true,
+	"security-opt": true,
+	"detach":       true,
+	"rm":           true,
+	"name":         true,
+	"interactive":  true,
+	"tty":          true,
+	"entrypoint":   true,
+}
+
+// DevContainer renders a .devcontainer/devcontainer.json using the image of the command.
+// Flags without a dev container property are passed with runArgs.
+// The command is left out as dev containers override it to keep the container running.
+func DevContainer(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	dc := devContainer{
+		Name:            s.Name,
+		Image:           s.Image,
+		ContainerEnv:    mappingMap(devContainerEnv(s.Environment)),
+		RemoteUser:      devContainerUser(s.User),
+		WorkspaceFolder: s.WorkingDir,
+		Privileged:      s.Privileged,
+		Init:            s.Init,
+		CapAdd:          s.CapAdd,
+		SecurityOpt:     s.SecurityOpt,
+	}
+
+	ports, err := parsePorts(portSpecs(s.Ports))
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range ports {
+		if !containsInt(dc.ForwardPorts, port.ContainerPort) {
+			dc.ForwardPorts = append(dc.ForwardPorts, port.ContainerPort)
+		}
+	}
+
+	for _, m := range serviceMounts(s) {
+		if m.Type == tmpfsMount {
+			continue
+		}
+		opts := []string{"source=" + m.Source, "target=" + m.Target, "type=" + m.Type}
+		if m.Source == "" {
+			opts = opts[1:]
+		}
+		if m.ReadOnly {
+			opts = append(opts, "readonly")
+		}
+		dc.Mounts = append(dc.Mounts, strings.Join(opts, ","))
+	}
+
+	for _, f := range p.Flags() {
+		// a user given by id or with a group is not a remote user and is passed as is
+		passUser := f.Name == "user" && dc.RemoteUser == ""
+		if f.Known() && (!devContainerFlags[f.Name] || passUser) {
+			dc.RunArgs = append(dc.RunArgs, f.Arg())
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(dc); err != nil {
+		return nil, err
+	}
+	return []File{{Name: ".devcontainer/devcontainer.json", Data: buf.Bytes()}}, nil
+}
+
+// devContainerUser returns the user as a remote user, which must be a user name.
+// It returns an empty string for a uid or a user with a group, eg: 1000:1000.
+func devContainerUser(user string) string {
+	if user == "" || strings.Contains(user, ":") {
+		return ""
+	}
+	if _, err := strconv.Atoi(user); err == nil {
+		return ""
+	}
+	return user
+}
+
+func containsInt(list []int, n int) bool {
+	for _, v := range list {
+		if v == n {
+			return true
+		}
+	}
+	return false
+}
+
+// devContainerEnv returns the container environment, where the variables passed through
+// from the host, eg: -e FOO, are taken from the local environment of the dev container.
+func devContainerEnv(env parser.Mapping) parser.Mapping {
+	result := make(parser.Mapping, 0, len(env))
+	for _, kv := range env {
+		if kv.Value == "" {
+			kv.Value = "${localEnv:" + kv.Key + "}"
+		}
+		result = append(result, kv)
+	}
+	return result
+}
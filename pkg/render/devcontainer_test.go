@@ -0,0 +1,76 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDevContainer(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "user name is the remote user",
+			command: "docker run -u node nginx",
+			want:    []string{`"remoteUser": "node"`},
+			notWant: []string{"runArgs"},
+		},
+		{
+			name:    "user with a group is passed to docker run",
+			command: "docker run -u 1000:1000 nginx",
+			want: []string{`"runArgs": [
+    "--user=1000:1000"
+  ]`},
+			notWant: []string{"remoteUser"},
+		},
+		{
+			name:    "user id is passed to docker run",
+			command: "docker run --user 1000 nginx",
+			want:    []string{`"--user=1000"`},
+			notWant: []string{"remoteUser"},
+		},
+		{
+			name:    "host environment is read from the local environment",
+			command: "docker run -e FOO -e BAR=1 nginx",
+			want: []string{`"containerEnv": {
+    "BAR": "1",
+    "FOO": "${localEnv:FOO}"
+  }`},
+		},
+		{
+			name:    "anonymous volume and tmpfs",
+			command: "docker run -v /cache --tmpfs /run nginx",
+			want: []string{`"mounts": [
+    "target=/cache,type=volume"
+  ],
+  "runArgs": [
+    "--tmpfs=/run"
+  ]`},
+		},
+		{
+			name:    "forwarded ports are the container ports",
+			command: "docker run -p 8080:80 -p 127.0.0.1:8443:80/udp -p 9000 nginx",
+			want: []string{`"forwardPorts": [
+    80,
+    9000
+  ]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "devcontainer", tt.command)
+			config := files[".devcontainer/devcontainer.json"]
+			for _, want := range tt.want {
+				require.Contains(t, config, want)
+			}
+			for _, notWant := range tt.notWant {
+				require.NotContains(t, config, notWant)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// ecsTaskDefinition is an AWS ECS task definition, see
+// https://docs.aws.amazon.com/AmazonECS/latest/developerguide/task_definition_parameters.html
+type ecsTaskDefinition struct {
+	Family                  string                   `json:"family"`
+	NetworkMode             string                   `json:"networkMode,omitempty"`
+	RequiresCompatibilities []string                 `json:"requiresCompatibilities"`
+	ContainerDefinitions    []ecsContainerDefinition `json:"containerDefinitions"`
+	Volumes                 []ecsVolume              `json:"volumes,omitempty"`
+}
+
+type ecsContainerDefinition struct {
+	Name                   string               `json:"name"`
+	Image                  string               `json:"image"`
+	Essential              bool                 `json:"essential"`
+	EntryPoint             []string             `json:"entryPoint,omitempty"`
+	Command                []string             `json:"command,omitempty"`
+	WorkingDirectory       string               `json:"workingDirectory,omitempty"`
+	User                   string               `json:"user,omitempty"`
+	Hostname               string               `json:"hostname,omitempty"`
+	CPU                    int                  `json:"cpu,omitempty"`
+	Memory                 int64                `json:"memory,omitempty"`
+	MemoryReservation      int64                `json:"memoryReservation,omitempty"`
+	PortMappings           []ecsPortMapping     `json:"portMappings,omitempty"`
+	Environment            []ecsKeyValue        `json:"environment,omitempty"`
+	MountPoints            []ecsMountPoint      `json:"mountPoints,omitempty"`
+	VolumesFrom            []ecsVolumeFrom      `json:"volumesFrom,omitempty"`
+	Links                  []string             `json:"links,omitempty"`
+	DNSServers             []string             `json:"dnsServers,omitempty"`
+	DNSSearchDomains       []string             `json:"dnsSearchDomains,omitempty"`
+	ExtraHosts             []ecsHostEntry       `json:"extraHosts,omitempty"`
+	Privileged             bool                 `json:"privileged,omitempty"`
+	ReadonlyRootFilesystem bool                 `json:"readonlyRootFilesystem,omitempty"`
+	Interactive            bool                 `json:"interactive,omitempty"`
+	PseudoTerminal         bool                 `json:"pseudoTerminal,omitempty"`
+	DockerLabels           map[string]string    `json:"dockerLabels,omitempty"`
+	DockerSecurityOptions  []string             `json:"dockerSecurityOptions,omitempty"`
+	Ulimits                []ecsUlimit          `json:"ulimits,omitempty"`
+	SystemControls         []ecsSystemControl   `json:"systemControls,omitempty"`
+	HealthCheck            *ecsHealthCheck      `json:"healthCheck,omitempty"`
+	LinuxParameters        *ecsLinuxParameters  `json:"linuxParameters,omitempty"`
+	LogConfiguration       *ecsLogConfiguration `json:"logConfiguration,omitempty"`
+	StopTimeout            int                  `json:"stopTimeout,omitempty"`
+}
+
+type ecsPortMapping struct {
+	ContainerPort int    `json:"containerPort"`
+	HostPort      int    `json:"hostPort,omitempty"`
+	Protocol      string `json:"protocol"`
+}
+
+type ecsKeyValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type ecsMountPoint struct {
+	SourceVolume  string `json:"sourceVolume"`
+	ContainerPath string `json:"containerPath"`
+	ReadOnly      bool   `json:"readOnly,omitempty"`
+}
+
+type ecsVolumeFrom struct {
+	SourceContainer string `json:"sourceContainer"`
+	ReadOnly        bool   `json:"readOnly,omitempty"`
+}
+
+type ecsHostEntry struct {
+	Hostname  string `json:"hostname"`
+	IPAddress string `json:"ipAddress"`
+}
+
+type ecsUlimit struct {
+	Name      string `json:"name"`
+	SoftLimit int    `json:"softLimit"`
+	HardLimit int    `json:"hardLimit"`
+}
+
+type ecsSystemControl struct {
+	Namespace string `json:"namespace"`
+	Value     string `json:"value"`
+}
+
+// ecsHealthCheck durations are in seconds.
+type ecsHealthCheck struct {
+	Command     []string `json:"command"`
+	Interval    int      `json:"interval,omitempty"`
+	Timeout     int      `json:"timeout,omitempty"`
+	Retries     int64    `json:"retries,omitempty"`
+	StartPeriod int      `json:"startPeriod,omitempty"`
+}
+
+// ecsLinuxParameters sizes are in MiB.
+type ecsLinuxParameters struct {
+	Capabilities *struct {
+		Add  []string `json:"add,omitempty"`
+		Drop []string `json:"drop,omitempty"`
+	} `json:"capabilities,omitempty"`
+	Devices            []ecsDevice `json:"devices,omitempty"`
+	InitProcessEnabled bool        `json:"initProcessEnabled,omitempty"`
+	SharedMemorySize   int64       `json:"sharedMemorySize,omitempty"`
+	Tmpfs              []ecsTmpfs  `json:"tmpfs,omitempty"`
+}
+
+type ecsDevice struct {
+	HostPath      string   `json:"hostPath"`
+	ContainerPath string   `json:"containerPath,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
+}
+
+type ecsTmpfs struct {
+	ContainerPath string   `json:"containerPath"`
+	Size          int64    `json:"size"`
+	MountOptions  []string `json:"mountOptions,omitempty"`
+}
+
+type ecsLogConfiguration struct {
+	LogDriver string            `json:"logDriver"`
+	Options   map[string]string `json:"options,omitempty"`
+}
+
+type ecsVolume struct {
+	Name string `json:"name"`
+	Host *struct {
+		SourcePath string `json:"sourcePath"`
+	} `json:"host,omitempty"`
+	DockerVolumeConfiguration *struct {
+		Scope         string `json:"scope"`
+		Autoprovision bool   `json:"autoprovision"`
+		Driver        string `json:"driver"`
+	} `json:"dockerVolumeConfiguration,omitempty"`
+}
+
+// ecsDefaultTmpfsSize is the size in MiB of tmpfs mounts without a size option, ECS requires a size.
+const ecsDefaultTmpfsSize = 64
+
+// ecsDevicePermissions maps the docker device cgroup permissions to ECS device permissions.
+var ecsDevicePermissions = map[rune]string{'r': "read", 'w': "write", 'm': "mknod"}
+
+// ECS renders an AWS ECS task definition for the EC2 launch type.
+// Named volumes become shared docker volumes and bind mounts become host volumes.
+// Published ports without a host port are mapped to a dynamic host port.
+// The host IP of published ports is left out and SCTP ports are an error, ECS only maps TCP and UDP ports.
+// Env files are left out as ECS only reads them from S3.
+func ECS(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	name := dnsName(s.Name)
+
+	c := ecsContainerDefinition{
+		Name:                   name,
+		Image:                  s.Image,
+		Essential:              true,
+		Command:                s.Command,
+		WorkingDirectory:       s.WorkingDir,
+		User:                   s.User,
+		Hostname:               s.Hostname,
+		Links:                  s.Links,
+		DNSServers:             s.DNS,
+		DNSSearchDomains:       s.DNSSearch,
+		Privileged:             s.Privileged,
+		ReadonlyRootFilesystem: s.ReadOnly,
+		Interactive:            s.StdinOpen,
+		PseudoTerminal:         s.Tty,
+		DockerLabels:           mappingMap(s.Labels),
+		DockerSecurityOptions:  s.SecurityOpt,
+	}
+	if s.Entrypoint != "" {
+		c.EntryPoint = []string{s.Entrypoint}
+	}
+	for _, kv := range definedEnv(s.Environment) {
+		c.Environment = append(c.Environment, ecsKeyValue{Name: kv.Key, Value: kv.Value})
+	}
+	for _, from := range s.VolumesFrom {
+		container, mode, _ := strings.Cut(from, ":")
+		c.VolumesFrom = append(c.VolumesFrom, ecsVolumeFrom{SourceContainer: container, ReadOnly: mode == "ro"})
+	}
+	for _, h := range s.ExtraHosts {
+		host, ip, _ := strings.Cut(h, ":")
+		c.ExtraHosts = append(c.ExtraHosts, ecsHostEntry{Hostname: host, IPAddress: ip})
+	}
+	for _, u := range s.Ulimits {
+		c.Ulimits = append(c.Ulimits, ecsUlimit{Name: u.Name, SoftLimit: u.Soft, HardLimit: u.Hard})
+	}
+	for _, kv := range s.Sysctls {
+		c.SystemControls = append(c.SystemControls, ecsSystemControl{Namespace: kv.Key, Value: kv.Value})
+	}
+	if s.Logging != nil {
+		c.LogConfiguration = &ecsLogConfiguration{LogDriver: s.Logging.Driver, Options: mappingMap(s.Logging.Options)}
+	}
+
+	ports, err := parsePorts(portSpecs(s.Ports))
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range ports {
+		if port.Protocol != "tcp" && port.Protocol != "udp" {
+			return nil, fmt.Errorf("ECS does not support %s port mappings", port.Protocol)
+		}
+		c.PortMappings = append(c.PortMappings, ecsPortMapping{ContainerPort: port.ContainerPort, HostPort: port.HostPort, Protocol: port.Protocol})
+	}
+
+	if err := ecsResources(&c, s); err != nil {
+		return nil, err
+	}
+	if c.HealthCheck, err = ecsHealth(s.Healthcheck); err != nil {
+		return nil, err
+	}
+	if timeout, ok, err := stopTimeout(s); err != nil {
+		return nil, err
+	} else if ok {
+		c.StopTimeout = int(timeout.Seconds())
+	}
+
+	task := &ecsTaskDefinition{
+		Family:                  name,
+		RequiresCompatibilities: []string{"EC2"},
+	}
+	switch s.NetworkMode {
+	case "bridge", "host", "none":
+		task.NetworkMode = s.NetworkMode
+	}
+
+	linux := &ecsLinuxParameters{InitProcessEnabled: s.Init}
+	if len(s.CapAdd) > 0 || len(s.CapDrop) > 0 {
+		linux.Capabilities = &struct {
+			Add  []string `json:"add,omitempty"`
+			Drop []string `json:"drop,omitempty"`
+		}{Add: k8sCapabilities(s.CapAdd), Drop: k8sCapabilities(s.CapDrop)}
+	}
+	for _, d := range s.Devices {
+		parts := strings.Split(d, ":")
+		device := ecsDevice{HostPath: parts[0]}
+		if len(parts) > 1 {
+			device.ContainerPath = parts[1]
+		}
+		if len(parts) > 2 {
+			for _, r := range parts[2] {
+				if permission, ok := ecsDevicePermissions[r]; ok {
+					device.Permissions = append(device.Permissions, permission)
+				}
+			}
+		}
+		linux.Devices = append(linux.Devices, device)
+	}
+	if s.ShmSize != "" {
+		size, err := parseBytes(s.ShmSize)
+		if err != nil {
+			return nil, err
+		}
+		linux.SharedMemorySize = mebibytes(size)
+	}
+
+	volumeNames := make(map[string]bool)
+	for _, m := range serviceMounts(s) {
+		if m.Type == tmpfsMount {
+			tmpfs := ecsTmpfs{ContainerPath: m.Target, Size: ecsDefaultTmpfsSize}
+			for _, opt := range strings.Split(m.Options, ",") {
+				if opt == "" {
+					continue
+				}
+				if size, ok := strings.CutPrefix(opt, "size="); ok {
+					bytes, err := parseBytes(size)
+					if err != nil {
+						return nil, err
+					}
+					tmpfs.Size = mebibytes(bytes)
+					continue
+				}
+				tmpfs.MountOptions = append(tmpfs.MountOptions, opt)
+			}
+			linux.Tmpfs = append(linux.Tmpfs, tmpfs)
+			continue
+		}
+
+		volume := ecsVolume{}
+		switch {
+		case m.Type == bindMount:
+			volume.Name = uniqueName(volumeNames, dnsName(m.Source))
+			volume.Host = &struct {
+				SourcePath string `json:"sourcePath"`
+			}{SourcePath: m.Source}
+		case m.Source != "":
+			volume.Name = uniqueName(volumeNames, m.Source)
+			volume.DockerVolumeConfiguration = &struct {
+				Scope         string `json:"scope"`
+				Autoprovision bool   `json:"autoprovision"`
+				Driver        string `json:"driver"`
+			}{Scope: "shared", Autoprovision: true, Driver: "local"}
+		default:
+			// anonymous volumes are task scoped volumes without a host path
+			volume.Name = uniqueName(volumeNames, dnsName(m.Target))
+		}
+		task.Volumes = append(task.Volumes, volume)
+		c.MountPoints = append(c.MountPoints, ecsMountPoint{SourceVolume: volume.Name, ContainerPath: m.Target, ReadOnly: m.ReadOnly})
+	}
+	if linux.Capabilities != nil || len(linux.Devices) > 0 || linux.InitProcessEnabled || linux.SharedMemorySize > 0 || len(linux.Tmpfs) > 0 {
+		c.LinuxParameters = linux
+	}
+
+	task.ContainerDefinitions = []ecsContainerDefinition{c}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(task); err != nil {
+		return nil, err
+	}
+	return []File{{Name: name + "-task-definition.json", Data: buf.Bytes()}}, nil
+}
+
+// ecsResources converts the deploy resources into CPU units, a CPU is 1024 units, and memory in MiB.
+func ecsResources(c *ecsContainerDefinition, s *parser.ServiceConfig) error {
+	r, err := serviceResources(s)
+	if err != nil {
+		return err
+	}
+	c.CPU = int(math.Round(r.CPUs * 1024))
+	c.Memory = mebibytes(r.Memory)
+	c.MemoryReservation = mebibytes(r.MemoryReservation)
+	return nil
+}
+
+func ecsHealth(h *parser.HealthCheckConfig) (*ecsHealthCheck, error) {
+	if h == nil || h.Test == "" || h.Disable {
+		return nil, nil
+	}
+	d, err := parseHealthDurations(h)
+	if err != nil {
+		return nil, err
+	}
+	return &ecsHealthCheck{
+		Command:     []string{"CMD-SHELL", h.Test},
+		Interval:    int(d.Interval.Seconds()),
+		Timeout:     int(d.Timeout.Seconds()),
+		Retries:     h.Retries,
+		StartPeriod: int(d.StartPeriod.Seconds()),
+	}, nil
+}
+
+// mebibytes converts bytes to MiB, rounding up.
+func mebibytes(b int64) int64 {
+	return (b + 1<<20 - 1) >> 20
+}
@@ -0,0 +1,83 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+func TestECS(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "port protocols",
+			command: "docker run -p 8080:80 -p 53:53/udp nginx",
+			want: `
+      "portMappings": [
+        {
+          "containerPort": 80,
+          "hostPort": 8080,
+          "protocol": "tcp"
+        },
+        {
+          "containerPort": 53,
+          "hostPort": 53,
+          "protocol": "udp"
+        }
+      ]
+`,
+		},
+		{
+			name:    "dynamic host port",
+			command: "docker run -p 9000 nginx",
+			want: `
+        {
+          "containerPort": 9000,
+          "protocol": "tcp"
+        }
+`,
+		},
+		{
+			name:    "port ranges and host IPs",
+			command: "docker run -p 127.0.0.1:7000-7001:7000-7001/udp nginx",
+			want: `
+        {
+          "containerPort": 7000,
+          "hostPort": 7000,
+          "protocol": "udp"
+        },
+        {
+          "containerPort": 7001,
+          "hostPort": 7001,
+          "protocol": "udp"
+        }
+`,
+		},
+		{
+			name:    "sctp ports",
+			command: "docker run -p 5000:5000/sctp nginx",
+			wantErr: "ECS does not support sctp port mappings",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.wantErr != "" {
+				p, err := parser.New(tt.command)
+				require.NoError(t, err)
+				require.NoError(t, p.Parse())
+				_, err = Render("ecs", p)
+				require.EqualError(t, err, tt.wantErr)
+				return
+			}
+			files := renderFiles(t, "ecs", tt.command)
+			require.Contains(t, files["nginx-task-definition.json"], tt.want)
+		})
+	}
+}
@@ -0,0 +1,431 @@
+package render
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// engineCreateRequest is the body of the docker engine API POST /containers/create request.
+// The container config is inlined next to the host and networking configs.
+// The field names follow the engine API, see https://docs.docker.com/engine/api/latest/#tag/Container/operation/ContainerCreate
+type engineCreateRequest struct {
+	engineConfig
+	HostConfig       *engineHostConfig       `json:",omitempty"`
+	NetworkingConfig *engineNetworkingConfig `json:",omitempty"`
+}
+
+type engineConfig struct {
+	Hostname     string              `json:",omitempty"`
+	Domainname   string              `json:",omitempty"`
+	User         string              `json:",omitempty"`
+	ExposedPorts map[string]struct{} `json:",omitempty"`
+	Tty          bool                `json:",omitempty"`
+	OpenStdin    bool                `json:",omitempty"`
+	Env          []string            `json:",omitempty"`
+	Cmd          []string            `json:",omitempty"`
+	Healthcheck  *engineHealthcheck  `json:",omitempty"`
+	Image        string
+	Volumes      map[string]struct{} `json:",omitempty"`
+	WorkingDir   string              `json:",omitempty"`
+	Entrypoint   []string            `json:",omitempty"`
+	MacAddress   string              `json:",omitempty"`
+	Labels       map[string]string   `json:",omitempty"`
+	StopSignal   string              `json:",omitempty"`
+	StopTimeout  *int                `json:",omitempty"`
+}
+
+// engineHealthcheck durations are in nanoseconds.
+type engineHealthcheck struct {
+	Test        []string
+	Interval    time.Duration `json:",omitempty"`
+	Timeout     time.Duration `json:",omitempty"`
+	Retries     int64         `json:",omitempty"`
+	StartPeriod time.Duration `json:",omitempty"`
+}
+
+type engineHostConfig struct {
+	Binds              []string                       `json:",omitempty"`
+	NetworkMode        string                         `json:",omitempty"`
+	PortBindings       map[string][]enginePortBinding `json:",omitempty"`
+	RestartPolicy      *engineRestartPolicy           `json:",omitempty"`
+	AutoRemove         bool                           `json:",omitempty"`
+	VolumesFrom        []string                       `json:",omitempty"`
+	CapAdd             []string                       `json:",omitempty"`
+	CapDrop            []string                       `json:",omitempty"`
+	CgroupnsMode       string                         `json:",omitempty"`
+	DNS                []string                       `json:"Dns,omitempty"`
+	DNSOptions         []string                       `json:"DnsOptions,omitempty"`
+	DNSSearch          []string                       `json:"DnsSearch,omitempty"`
+	ExtraHosts         []string                       `json:",omitempty"`
+	GroupAdd           []string                       `json:",omitempty"`
+	IpcMode            string                         `json:",omitempty"`
+	Links              []string                       `json:",omitempty"`
+	OomScoreAdj        int64                          `json:",omitempty"`
+	PidMode            string                         `json:",omitempty"`
+	Privileged         bool                           `json:",omitempty"`
+	ReadonlyRootfs     bool                           `json:",omitempty"`
+	SecurityOpt        []string                       `json:",omitempty"`
+	StorageOpt         map[string]string              `json:",omitempty"`
+	Tmpfs              map[string]string              `json:",omitempty"`
+	UTSMode            string                         `json:",omitempty"`
+	UsernsMode         string                         `json:",omitempty"`
+	ShmSize            int64                          `json:",omitempty"`
+	Sysctls            map[string]string              `json:",omitempty"`
+	Runtime            string                         `json:",omitempty"`
+	Isolation          string                         `json:",omitempty"`
+	Init               *bool                          `json:",omitempty"`
+	LogConfig          *engineLogConfig               `json:",omitempty"`
+	CPUShares          int64                          `json:"CpuShares,omitempty"`
+	Memory             int64                          `json:",omitempty"`
+	NanoCPUs           int64                          `json:"NanoCpus,omitempty"`
+	CgroupParent       string                         `json:",omitempty"`
+	BlkioWeight        int64                          `json:",omitempty"`
+	CPUPeriod          int64                          `json:"CpuPeriod,omitempty"`
+	CPUQuota           int64                          `json:"CpuQuota,omitempty"`
+	CPURealtimePeriod  int64                          `json:"CpuRealtimePeriod,omitempty"`
+	CPURealtimeRuntime int64                          `json:"CpuRealtimeRuntime,omitempty"`
+	CpusetCpus         string                         `json:",omitempty"`
+	CpusetMems         string                         `json:",omitempty"`
+	Devices            []engineDevice                 `json:",omitempty"`
+	DeviceCgroupRules  []string                       `json:",omitempty"`
+	MemoryReservation  int64                          `json:",omitempty"`
+	MemorySwap         int64                          `json:",omitempty"`
+	MemorySwappiness   *int64                         `json:",omitempty"`
+	OomKillDisable     *bool                          `json:",omitempty"`
+	PidsLimit          *int64                         `json:",omitempty"`
+	Ulimits            []*engineUlimit                `json:",omitempty"`
+	Mounts             []engineMount                  `json:",omitempty"`
+}
+
+type enginePortBinding struct {
+	HostIP   string `json:"HostIp"`
+	HostPort string
+}
+
+type engineRestartPolicy struct {
+	Name              string
+	MaximumRetryCount int `json:",omitempty"`
+}
+
+type engineLogConfig struct {
+	Type   string
+	Config map[string]string `json:",omitempty"`
+}
+
+type engineDevice struct {
+	PathOnHost        string
+	PathInContainer   string
+	CgroupPermissions string
+}
+
+type engineUlimit struct {
+	Name string
+	Soft int
+	Hard int
+}
+
+type engineMount struct {
+	Type        string
+	Source      string `json:",omitempty"`
+	Target      string
+	ReadOnly    bool `json:",omitempty"`
+	BindOptions *struct {
+		Propagation string
+	} `json:",omitempty"`
+	TmpfsOptions *struct {
+		SizeBytes int64
+	} `json:",omitempty"`
+}
+
+type engineNetworkingConfig struct {
+	EndpointsConfig map[string]*engineEndpointSettings
+}
+
+type engineEndpointSettings struct {
+	IPAMConfig *struct {
+		IPv4Address string `json:",omitempty"`
+		IPv6Address string `json:",omitempty"`
+	} `json:",omitempty"`
+	Aliases []string `json:",omitempty"`
+}
+
+// EngineJSON renders the docker engine API ContainerCreate request body.
+// The container name is not part of the body, it is passed with the name query parameter.
+func EngineJSON(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	req := engineCreateRequest{
+		engineConfig: engineConfig{
+			Hostname:   s.Hostname,
+			Domainname: s.Domainname,
+			User:       s.User,
+			Tty:        s.Tty,
+			OpenStdin:  s.StdinOpen,
+			Env:        envList(s.Environment),
+			Cmd:        s.Command,
+			Image:      s.Image,
+			WorkingDir: s.WorkingDir,
+			MacAddress: s.MacAddress,
+			Labels:     mappingMap(s.Labels),
+			StopSignal: s.StopSignal,
+		},
+	}
+	if s.Entrypoint != "" {
+		req.Entrypoint = []string{s.Entrypoint}
+	}
+
+	var err error
+	if req.Healthcheck, err = engineHealth(s.Healthcheck); err != nil {
+		return nil, err
+	}
+	if timeout, ok, err := stopTimeout(s); err != nil {
+		return nil, err
+	} else if ok {
+		seconds := int(timeout.Seconds())
+		req.StopTimeout = &seconds
+	}
+
+	host := &engineHostConfig{
+		NetworkMode:        s.NetworkMode,
+		VolumesFrom:        s.VolumesFrom,
+		CapAdd:             s.CapAdd,
+		CapDrop:            s.CapDrop,
+		CgroupnsMode:       s.CgroupnsMode,
+		DNS:                s.DNS,
+		DNSOptions:         s.DNSOpt,
+		DNSSearch:          s.DNSSearch,
+		ExtraHosts:         s.ExtraHosts,
+		GroupAdd:           s.GroupAdd,
+		IpcMode:            s.Ipc,
+		Links:              s.Links,
+		OomScoreAdj:        s.OomScoreAdj,
+		PidMode:            s.Pid,
+		Privileged:         s.Privileged,
+		ReadonlyRootfs:     s.ReadOnly,
+		SecurityOpt:        s.SecurityOpt,
+		StorageOpt:         mappingMap(s.StorageOpt),
+		UTSMode:            s.Uts,
+		UsernsMode:         s.UsernsMode,
+		Sysctls:            mappingMap(s.Sysctls),
+		Runtime:            s.Runtime,
+		Isolation:          s.Isolation,
+		CPUShares:          s.CPUShares,
+		CgroupParent:       s.CgroupParent,
+		CPUPeriod:          s.CPUPeriod,
+		CPUQuota:           s.CPUQuota,
+		CPURealtimePeriod:  s.CPURTPeriod,
+		CPURealtimeRuntime: s.CPURTRuntime,
+		CpusetCpus:         s.Cpuset,
+		CpusetMems:         s.CpusetMems,
+		DeviceCgroupRules:  s.DeviceCgroupRules,
+		MemorySwappiness:   s.MemSwappiness,
+	}
+	if s.Init {
+		host.Init = &s.Init
+	}
+	if s.OomKillDisable {
+		host.OomKillDisable = &s.OomKillDisable
+	}
+	if s.PidsLimit != 0 {
+		host.PidsLimit = &s.PidsLimit
+	}
+	if s.BlkioConfig != nil {
+		host.BlkioWeight = s.BlkioConfig.Weight
+	}
+	for _, f := range p.Flags() {
+		if f.Name == "rm" {
+			host.AutoRemove = f.Value == "true"
+		}
+	}
+
+	if err := enginePorts(&req, host, s); err != nil {
+		return nil, err
+	}
+	if err := engineResources(host, s); err != nil {
+		return nil, err
+	}
+	if err := engineMounts(&req, host, s); err != nil {
+		return nil, err
+	}
+
+	if s.Restart != "" {
+		name, count, _ := strings.Cut(s.Restart, ":")
+		host.RestartPolicy = &engineRestartPolicy{Name: name}
+		if count != "" {
+			if host.RestartPolicy.MaximumRetryCount, err = strconv.Atoi(count); err != nil {
+				return nil, fmt.Errorf("invalid restart policy %q", s.Restart)
+			}
+		}
+	}
+	if s.Logging != nil {
+		host.LogConfig = &engineLogConfig{Type: s.Logging.Driver, Config: mappingMap(s.Logging.Options)}
+	}
+	if s.ShmSize != "" {
+		if host.ShmSize, err = parseBytes(s.ShmSize); err != nil {
+			return nil, err
+		}
+	}
+	for _, d := range s.Devices {
+		parts := strings.Split(d, ":")
+		device := engineDevice{PathOnHost: parts[0], PathInContainer: parts[0], CgroupPermissions: "rwm"}
+		if len(parts) > 1 {
+			device.PathInContainer = parts[1]
+		}
+		if len(parts) > 2 {
+			device.CgroupPermissions = parts[2]
+		}
+		host.Devices = append(host.Devices, device)
+	}
+	for _, u := range s.Ulimits {
+		host.Ulimits = append(host.Ulimits, &engineUlimit{Name: u.Name, Soft: u.Soft, Hard: u.Hard})
+	}
+	req.HostConfig = host
+
+	if n := s.Networks["default"]; n != nil {
+		network := s.NetworkMode
+		if network == "" {
+			network = "default"
+		}
+		endpoint := &engineEndpointSettings{Aliases: n.Aliases}
+		if n.Ipv4Address != "" || n.Ipv6Address != "" {
+			endpoint.IPAMConfig = &struct {
+				IPv4Address string `json:",omitempty"`
+				IPv6Address string `json:",omitempty"`
+			}{IPv4Address: n.Ipv4Address, IPv6Address: n.Ipv6Address}
+		}
+		req.NetworkingConfig = &engineNetworkingConfig{
+			EndpointsConfig: map[string]*engineEndpointSettings{network: endpoint},
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(req); err != nil {
+		return nil, err
+	}
+	return []File{{Name: s.Name + ".json", Data: buf.Bytes()}}, nil
+}
+
+func engineHealth(h *parser.HealthCheckConfig) (*engineHealthcheck, error) {
+	if h == nil {
+		return nil, nil
+	}
+	if h.Disable {
+		return &engineHealthcheck{Test: []string{"NONE"}}, nil
+	}
+	d, err := parseHealthDurations(h)
+	if err != nil {
+		return nil, err
+	}
+	health := &engineHealthcheck{Retries: h.Retries, Interval: d.Interval, Timeout: d.Timeout, StartPeriod: d.StartPeriod}
+	if h.Test != "" {
+		health.Test = []string{"CMD-SHELL", h.Test}
+	}
+	return health, nil
+}
+
+func enginePorts(req *engineCreateRequest, host *engineHostConfig, s *parser.ServiceConfig) error {
+	ports, err := servicePorts(s)
+	if err != nil {
+		return err
+	}
+	for _, port := range ports {
+		key := fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol)
+		if req.ExposedPorts == nil {
+			req.ExposedPorts = make(map[string]struct{})
+		}
+		req.ExposedPorts[key] = struct{}{}
+		if !port.Published {
+			continue
+		}
+		binding := enginePortBinding{HostIP: port.HostIP}
+		if port.HostPort != 0 {
+			binding.HostPort = strconv.Itoa(port.HostPort)
+		}
+		if host.PortBindings == nil {
+			host.PortBindings = make(map[string][]enginePortBinding)
+		}
+		host.PortBindings[key] = append(host.PortBindings[key], binding)
+	}
+	return nil
+}
+
+func engineResources(host *engineHostConfig, s *parser.ServiceConfig) error {
+	r, err := serviceResources(s)
+	if err != nil {
+		return err
+	}
+	host.NanoCPUs = int64(math.Round(r.CPUs * 1e9))
+	host.Memory = r.Memory
+	host.MemoryReservation = r.MemoryReservation
+	host.MemorySwap = r.MemorySwap
+	return nil
+}
+
+// engineMounts converts -v values into binds, anonymous volumes into config volumes,
+// --mount values into mounts and --tmpfs values into the tmpfs map.
+func engineMounts(req *engineCreateRequest, host *engineHostConfig, s *parser.ServiceConfig) error {
+	for _, v := range s.Volumes {
+		if v.Mount == nil {
+			if strings.Contains(v.Spec, ":") {
+				host.Binds = append(host.Binds, v.Spec)
+				continue
+			}
+			if req.Volumes == nil {
+				req.Volumes = make(map[string]struct{})
+			}
+			req.Volumes[v.Spec] = struct{}{}
+			continue
+		}
+
+		m := engineMount{
+			Type:     v.Mount.Type,
+			Source:   v.Mount.Source,
+			Target:   v.Mount.Target,
+			ReadOnly: v.Mount.Readonly == "true" || v.Mount.Readonly == "1",
+		}
+		if m.Type == "" {
+			m.Type = volumeMount
+		}
+		if v.Mount.BindPropagation != "" {
+			m.BindOptions = &struct{ Propagation string }{Propagation: v.Mount.BindPropagation}
+		}
+		if v.Mount.TmpfsSize != "" {
+			size, err := parseBytes(v.Mount.TmpfsSize)
+			if err != nil {
+				return err
+			}
+			m.TmpfsOptions = &struct{ SizeBytes int64 }{SizeBytes: size}
+		}
+		host.Mounts = append(host.Mounts, m)
+	}
+
+	for _, t := range s.Tmpfs {
+		target, options, _ := strings.Cut(t, ":")
+		if host.Tmpfs == nil {
+			host.Tmpfs = make(map[string]string)
+		}
+		host.Tmpfs[target] = options
+	}
+	return nil
+}
+
+// mappingMap converts a Mapping into a map, returning nil for an empty Mapping.
+func mappingMap(m parser.Mapping) map[string]string {
+	if len(m) == 0 {
+		return nil
+	}
+	result := make(map[string]string, len(m))
+	for _, kv := range m {
+		result[kv.Key] = kv.Value
+	}
+	return result
+}
@@ -0,0 +1,111 @@
+package render
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEngineJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		// want maps the path of a field, eg: HostConfig.PortBindings, to its JSON value.
+		// An empty value expects the field to be left out.
+		want map[string]string
+	}{
+		{
+			name:    "port bindings and exposed ports",
+			command: "docker run -p 127.0.0.1:8080:80 -p 53:53/udp -p 9000 --expose 7000 nginx",
+			want: map[string]string{
+				"ExposedPorts": `{"53/udp": {}, "7000/tcp": {}, "80/tcp": {}, "9000/tcp": {}}`,
+				"HostConfig.PortBindings": `{
+					"53/udp": [{"HostIp": "", "HostPort": "53"}],
+					"80/tcp": [{"HostIp": "127.0.0.1", "HostPort": "8080"}],
+					"9000/tcp": [{"HostIp": "", "HostPort": ""}]
+				}`,
+			},
+		},
+		{
+			name:    "binds, anonymous volumes, mounts and tmpfs",
+			command: "docker run -v /srv:/srv -v /anon -v data:/data --mount type=tmpfs,dst=/run,tmpfs-size=64m --tmpfs /tmp:size=1m nginx",
+			want: map[string]string{
+				"Volumes":           `{"/anon": {}}`,
+				"HostConfig.Binds":  `["/srv:/srv", "data:/data"]`,
+				"HostConfig.Tmpfs":  `{"/tmp": "size=1m"}`,
+				"HostConfig.Mounts": `[{"Type": "tmpfs", "Target": "/run", "TmpfsOptions": {"SizeBytes": 67108864}}]`,
+			},
+		},
+		{
+			name:    "restart policy with a retry count",
+			command: "docker run --restart on-failure:3 --rm nginx",
+			want: map[string]string{
+				"HostConfig.RestartPolicy": `{"Name": "on-failure", "MaximumRetryCount": 3}`,
+				"HostConfig.AutoRemove":    `true`,
+			},
+		},
+		{
+			name:    "health check durations in nanoseconds",
+			command: "docker run --health-cmd 'curl -f localhost' --health-interval 30s --health-retries 3 nginx",
+			want: map[string]string{
+				"Healthcheck": `{"Test": ["CMD-SHELL", "curl -f localhost"], "Interval": 30000000000, "Retries": 3}`,
+			},
+		},
+		{
+			name:    "health check disabled",
+			command: "docker run --no-healthcheck nginx",
+			want:    map[string]string{"Healthcheck": `{"Test": ["NONE"]}`},
+		},
+		{
+			name:    "host environment is left out",
+			command: "docker run -e FOO -e A=1 nginx",
+			want:    map[string]string{"Env": `["A=1"]`},
+		},
+		{
+			name:    "devices get all cgroup permissions by default",
+			command: "docker run --device /dev/fuse --device /dev/sda:/dev/xvda:r nginx",
+			want: map[string]string{
+				"HostConfig.Devices": `[
+					{"PathOnHost": "/dev/fuse", "PathInContainer": "/dev/fuse", "CgroupPermissions": "rwm"},
+					{"PathOnHost": "/dev/sda", "PathInContainer": "/dev/xvda", "CgroupPermissions": "r"}
+				]`,
+			},
+		},
+		{
+			name:    "endpoint of the network",
+			command: "docker run --network backend --ip 10.0.0.2 --network-alias web nginx",
+			want: map[string]string{
+				"HostConfig.NetworkMode":           `"backend"`,
+				"NetworkingConfig.EndpointsConfig": `{"backend": {"IPAMConfig": {"IPv4Address": "10.0.0.2"}, "Aliases": ["web"]}}`,
+			},
+		},
+		{
+			name:    "no networking config without endpoint settings",
+			command: "docker run --network backend nginx",
+			want:    map[string]string{"NetworkingConfig": ""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "engine-json", tt.command)
+			var body interface{}
+			require.NoError(t, json.Unmarshal([]byte(files["nginx.json"]), &body))
+			for path, want := range tt.want {
+				value := body
+				for _, key := range strings.Split(path, ".") {
+					value = value.(map[string]interface{})[key]
+				}
+				if want == "" {
+					require.Nil(t, value, path)
+					continue
+				}
+				got, err := json.Marshal(value)
+				require.NoError(t, err)
+				require.JSONEq(t, want, string(got), path)
+			}
+		})
+	}
+}
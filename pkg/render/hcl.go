@@ -0,0 +1,198 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// hclBlock is a block of a HashiCorp configuration language file, eg: job "web" { ... }.
+// Attribute values are encoded when they are added.
+type hclBlock struct {
+	typ    string
+	labels []string
+	items  []hclItem
+}
+
+// hclItem is either an attribute or a nested block.
+type hclItem struct {
+	name  string
+	value string
+	block *hclBlock
+}
+
+// hclExpression is an expression which is written as is, eg: a reference to another resource.
+type hclExpression string
+
+// attr adds an attribute. Zero values are left out.
+// value is a string, bool, int, int64, float64, []string, parser.Mapping or hclExpression.
+func (b *hclBlock) attr(name string, value interface{}) {
+	switch v := value.(type) {
+	case string:
+		if v == "" {
+			return
+		}
+	case hclExpression:
+		if v == "" {
+			return
+		}
+	case bool:
+		if !v {
+			return
+		}
+	case int:
+		if v == 0 {
+			return
+		}
+	case int64:
+		if v == 0 {
+			return
+		}
+	case float64:
+		if v == 0 {
+			return
+		}
+	case []string:
+		if len(v) == 0 {
+			return
+		}
+	case parser.Mapping:
+		if len(v) == 0 {
+			return
+		}
+	}
+	b.set(name, value)
+}
+
+// set adds an attribute, including zero values.
+func (b *hclBlock) set(name string, value interface{}) {
+	b.items = append(b.items, hclItem{name: name, value: hclValue(value)})
+}
+
+// block adds a nested block.
+func (b *hclBlock) block(typ string, labels ...string) *hclBlock {
+	block := &hclBlock{typ: typ, labels: labels}
+	b.items = append(b.items, hclItem{block: block})
+	return block
+}
+
+// Bytes encodes the block the way terraform fmt formats it.
+func (b *hclBlock) Bytes() []byte {
+	var buf bytes.Buffer
+	b.write(&buf, 0)
+	return buf.Bytes()
+}
+
+func (b *hclBlock) write(buf *bytes.Buffer, depth int) {
+	indent := strings.Repeat("  ", depth)
+	buf.WriteString(indent + b.typ)
+	for _, label := range b.labels {
+		buf.WriteString(" " + hclString(label))
+	}
+	buf.WriteString(" {\n")
+
+	for i, item := range b.items {
+		if item.block != nil {
+			if i > 0 {
+				buf.WriteByte('\n')
+			}
+			item.block.write(buf, depth+1)
+			if i < len(b.items)-1 && b.items[i+1].block == nil {
+				buf.WriteByte('\n')
+			}
+			continue
+		}
+		// the equal signs of consecutive attributes are aligned
+		start, end := i, i
+		for start > 0 && b.items[start-1].block == nil {
+			start--
+		}
+		for end < len(b.items)-1 && b.items[end+1].block == nil {
+			end++
+		}
+		width := 0
+		for _, attr := range b.items[start : end+1] {
+			if n := len(hclName(attr.name)); n > width {
+				width = n
+			}
+		}
+		// the lines of a multi-line value are indented with the attribute
+		value := strings.ReplaceAll(item.value, "\n", "\n"+indent+"  ")
+		fmt.Fprintf(buf, "%s  %-*s = %s\n", indent, width, hclName(item.name), value)
+	}
+	buf.WriteString(indent + "}\n")
+}
+
+func hclValue(value interface{}) string {
+	switch v := value.(type) {
+	case hclExpression:
+		return string(v)
+	case string:
+		return hclString(v)
+	case bool:
+		return strconv.FormatBool(v)
+	case int:
+		return strconv.Itoa(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case []string:
+		items := make([]string, len(v))
+		for i, s := range v {
+			items[i] = hclString(s)
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case parser.Mapping:
+		if len(v) == 1 {
+			return "{ " + hclName(v[0].Key) + " = " + hclString(v[0].Value) + " }"
+		}
+		// larger objects are written one aligned entry per line
+		width := 0
+		for _, kv := range v {
+			if n := len(hclName(kv.Key)); n > width {
+				width = n
+			}
+		}
+		var buf strings.Builder
+		buf.WriteString("{\n")
+		for _, kv := range v {
+			fmt.Fprintf(&buf, "  %-*s = %s\n", width, hclName(kv.Key), hclString(kv.Value))
+		}
+		buf.WriteString("}")
+		return buf.String()
+	}
+	panic(fmt.Sprintf("unsupported hcl value %T", value))
+}
+
+// hclString quotes a string, escaping template sequences.
+func hclString(s string) string {
+	s = strings.NewReplacer(
+		`\`, `\\`,
+		`"`, `\"`,
+		"\n", `\n`,
+		"\t", `\t`,
+		"${", "$${",
+		"%{", "%%{",
+	).Replace(s)
+	return `"` + s + `"`
+}
+
+// hclName returns an attribute or object key name, quoting it if it is not an identifier.
+func hclName(name string) string {
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r == '-' || r >= '0' && r <= '9'):
+		default:
+			return hclString(name)
+		}
+	}
+	if name == "" {
+		return `""`
+	}
+	return name
+}
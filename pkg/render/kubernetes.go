@@ -0,0 +1,456 @@
+package render
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+type k8sMetadata struct {
+	Name        string            `yaml:"name,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type k8sDeployment struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Spec       k8sDeploymentSpec `yaml:"spec"`
+}
+
+type k8sDeploymentSpec struct {
+	Replicas int `yaml:"replicas"`
+	Selector struct {
+		MatchLabels map[string]string `yaml:"matchLabels"`
+	} `yaml:"selector"`
+	Template struct {
+		Metadata k8sMetadata `yaml:"metadata"`
+		Spec     k8sPodSpec  `yaml:"spec"`
+	} `yaml:"template"`
+}
+
+type k8sPodSpec struct {
+	Hostname   string         `yaml:"hostname,omitempty"`
+	Subdomain  string         `yaml:"subdomain,omitempty"`
+	Containers []k8sContainer `yaml:"containers"`
+	Volumes    []k8sVolume    `yaml:"volumes,omitempty"`
+}
+
+type k8sContainer struct {
+	Name            string              `yaml:"name"`
+	Image           string              `yaml:"image"`
+	Command         []string            `yaml:"command,omitempty"`
+	Args            []string            `yaml:"args,omitempty"`
+	WorkingDir      string              `yaml:"workingDir,omitempty"`
+	Ports           []k8sContainerPort  `yaml:"ports,omitempty"`
+	EnvFrom         []k8sEnvFrom        `yaml:"envFrom,omitempty"`
+	Resources       *k8sResources       `yaml:"resources,omitempty"`
+	VolumeMounts    []k8sVolumeMount    `yaml:"volumeMounts,omitempty"`
+	LivenessProbe   *k8sProbe           `yaml:"livenessProbe,omitempty"`
+	SecurityContext *k8sSecurityContext `yaml:"securityContext,omitempty"`
+	Stdin           bool                `yaml:"stdin,omitempty"`
+	TTY             bool                `yaml:"tty,omitempty"`
+}
+
+type k8sContainerPort struct {
+	ContainerPort int    `yaml:"containerPort"`
+	Protocol      string `yaml:"protocol"`
+}
+
+type k8sEnvFrom struct {
+	ConfigMapRef *k8sNameRef `yaml:"configMapRef,omitempty"`
+	SecretRef    *k8sNameRef `yaml:"secretRef,omitempty"`
+}
+
+type k8sNameRef struct {
+	Name string `yaml:"name"`
+}
+
+type k8sResources struct {
+	Limits   map[string]string `yaml:"limits,omitempty"`
+	Requests map[string]string `yaml:"requests,omitempty"`
+}
+
+type k8sVolumeMount struct {
+	Name      string `yaml:"name"`
+	MountPath string `yaml:"mountPath"`
+	ReadOnly  bool   `yaml:"readOnly,omitempty"`
+}
+
+type k8sVolume struct {
+	Name                  string `yaml:"name"`
+	PersistentVolumeClaim *struct {
+		ClaimName string `yaml:"claimName"`
+	} `yaml:"persistentVolumeClaim,omitempty"`
+	HostPath *struct {
+		Path string `yaml:"path"`
+	} `yaml:"hostPath,omitempty"`
+	EmptyDir *struct {
+		Medium    string `yaml:"medium,omitempty"`
+		SizeLimit string `yaml:"sizeLimit,omitempty"`
+	} `yaml:"emptyDir,omitempty"`
+}
+
+type k8sProbe struct {
+	Exec struct {
+		Command []string `yaml:"command"`
+	} `yaml:"exec"`
+	InitialDelaySeconds int   `yaml:"initialDelaySeconds,omitempty"`
+	PeriodSeconds       int   `yaml:"periodSeconds,omitempty"`
+	TimeoutSeconds      int   `yaml:"timeoutSeconds,omitempty"`
+	FailureThreshold    int64 `yaml:"failureThreshold,omitempty"`
+}
+
+type k8sSecurityContext struct {
+	Privileged             bool   `yaml:"privileged,omitempty"`
+	ReadOnlyRootFilesystem bool   `yaml:"readOnlyRootFilesystem,omitempty"`
+	RunAsUser              *int64 `yaml:"runAsUser,omitempty"`
+	RunAsGroup             *int64 `yaml:"runAsGroup,omitempty"`
+	Capabilities           *struct {
+		Add  []string `yaml:"add,omitempty"`
+		Drop []string `yaml:"drop,omitempty"`
+	} `yaml:"capabilities,omitempty"`
+}
+
+type k8sService struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   k8sMetadata `yaml:"metadata"`
+	Spec       struct {
+		Selector map[string]string `yaml:"selector"`
+		Ports    []k8sServicePort  `yaml:"ports"`
+	} `yaml:"spec"`
+}
+
+type k8sServicePort struct {
+	Name       string `yaml:"name"`
+	Port       int    `yaml:"port"`
+	TargetPort int    `yaml:"targetPort"`
+	Protocol   string `yaml:"protocol"`
+}
+
+type k8sConfigMap struct {
+	APIVersion string            `yaml:"apiVersion"`
+	Kind       string            `yaml:"kind"`
+	Metadata   k8sMetadata       `yaml:"metadata"`
+	Type       string            `yaml:"type,omitempty"`
+	Data       map[string]string `yaml:"data,omitempty"`
+	StringData map[string]string `yaml:"stringData,omitempty"`
+}
+
+type k8sPersistentVolumeClaim struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   k8sMetadata `yaml:"metadata"`
+	Spec       struct {
+		AccessModes []string `yaml:"accessModes"`
+		Resources   struct {
+			Requests map[string]string `yaml:"requests"`
+		} `yaml:"resources"`
+	} `yaml:"spec"`
+}
+
+// defaultVolumeSize is the storage requested by the persistent volume claims of named volumes.
+const defaultVolumeSize = "1Gi"
+
+// secretEnvNames are parts of environment variable names which are put into a Secret instead of a ConfigMap.
+var secretEnvNames = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "API_KEY", "PRIVATE_KEY", "CREDENTIAL"}
+
+// Kubernetes renders a Deployment with a Service for the published ports, a ConfigMap and
+// a Secret for the environment and PersistentVolumeClaims for the named volumes.
+// The environment variables are guessed to be secrets from their names, see secretEnvNames.
+// A hostname like web.local is split into the pod hostname and subdomain.
+func Kubernetes(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	name := dnsName(s.Name)
+	labels := map[string]string{"app": name}
+
+	container := k8sContainer{
+		Name:       name,
+		Image:      s.Image,
+		Args:       s.Command,
+		WorkingDir: s.WorkingDir,
+		Stdin:      s.StdinOpen,
+		TTY:        s.Tty,
+	}
+	if s.Entrypoint != "" {
+		container.Command = []string{s.Entrypoint}
+	}
+
+	ports, err := servicePorts(s)
+	if err != nil {
+		return nil, err
+	}
+	service := &k8sService{
+		APIVersion: "v1",
+		Kind:       "Service",
+		Metadata:   k8sMetadata{Name: name, Labels: labels},
+	}
+	service.Spec.Selector = labels
+	for _, port := range ports {
+		protocol := strings.ToUpper(port.Protocol)
+		container.Ports = append(container.Ports, k8sContainerPort{ContainerPort: port.ContainerPort, Protocol: protocol})
+		if !port.Published {
+			continue
+		}
+		servicePort := port.HostPort
+		if servicePort == 0 {
+			servicePort = port.ContainerPort
+		}
+		service.Spec.Ports = append(service.Spec.Ports, k8sServicePort{
+			Name:       fmt.Sprintf("%s-%d", port.Protocol, servicePort),
+			Port:       servicePort,
+			TargetPort: port.ContainerPort,
+			Protocol:   protocol,
+		})
+	}
+
+	var docs []interface{}
+
+	config := &k8sConfigMap{APIVersion: "v1", Kind: "ConfigMap", Metadata: k8sMetadata{Name: name + "-config", Labels: labels}}
+	secret := &k8sConfigMap{APIVersion: "v1", Kind: "Secret", Metadata: k8sMetadata{Name: name + "-secret", Labels: labels}, Type: "Opaque"}
+	for _, kv := range definedEnv(s.Environment) {
+		if isSecretEnv(kv.Key) {
+			if secret.StringData == nil {
+				secret.StringData = make(map[string]string)
+			}
+			secret.StringData[kv.Key] = kv.Value
+			continue
+		}
+		if config.Data == nil {
+			config.Data = make(map[string]string)
+		}
+		config.Data[kv.Key] = kv.Value
+	}
+	if config.Data != nil {
+		docs = append(docs, config)
+		container.EnvFrom = append(container.EnvFrom, k8sEnvFrom{ConfigMapRef: &k8sNameRef{Name: config.Metadata.Name}})
+	}
+	if secret.StringData != nil {
+		docs = append(docs, secret)
+		container.EnvFrom = append(container.EnvFrom, k8sEnvFrom{SecretRef: &k8sNameRef{Name: secret.Metadata.Name}})
+	}
+
+	deployment := &k8sDeployment{
+		APIVersion: "apps/v1",
+		Kind:       "Deployment",
+		Metadata:   k8sMetadata{Name: name, Labels: labels},
+	}
+	deployment.Spec.Replicas = 1
+	if s.Deploy != nil && s.Deploy.Replicas != nil {
+		deployment.Spec.Replicas = int(*s.Deploy.Replicas)
+	}
+	deployment.Spec.Selector.MatchLabels = labels
+	deployment.Spec.Template.Metadata = k8sMetadata{Labels: labels, Annotations: k8sAnnotations(s)}
+	deployment.Spec.Template.Spec.Hostname, deployment.Spec.Template.Spec.Subdomain = k8sHostname(s.Hostname)
+
+	volumeNames := make(map[string]bool)
+	for _, m := range serviceMounts(s) {
+		volumeName := dnsName(m.Target)
+		if m.Type == volumeMount && m.Source != "" {
+			volumeName = dnsName(m.Source)
+		}
+		volume := k8sVolume{Name: uniqueName(volumeNames, volumeName)}
+		switch {
+		case m.Type == volumeMount && m.Source != "":
+			volume.PersistentVolumeClaim = &struct {
+				ClaimName string `yaml:"claimName"`
+			}{ClaimName: volume.Name}
+
+			claim := &k8sPersistentVolumeClaim{APIVersion: "v1", Kind: "PersistentVolumeClaim", Metadata: k8sMetadata{Name: volume.Name, Labels: labels}}
+			claim.Spec.AccessModes = []string{"ReadWriteOnce"}
+			claim.Spec.Resources.Requests = map[string]string{"storage": defaultVolumeSize}
+			docs = append(docs, claim)
+		case m.Type == bindMount:
+			volume.HostPath = &struct {
+				Path string `yaml:"path"`
+			}{Path: m.Source}
+		default:
+			volume.EmptyDir = &struct {
+				Medium    string `yaml:"medium,omitempty"`
+				SizeLimit string `yaml:"sizeLimit,omitempty"`
+			}{}
+			if m.Type == tmpfsMount {
+				volume.EmptyDir.Medium = "Memory"
+				if size := tmpfsSize(m.Options); size != "" {
+					b, err := parseBytes(size)
+					if err != nil {
+						return nil, err
+					}
+					volume.EmptyDir.SizeLimit = k8sQuantity(b)
+				}
+			}
+		}
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, volume)
+		container.VolumeMounts = append(container.VolumeMounts, k8sVolumeMount{Name: volume.Name, MountPath: m.Target, ReadOnly: m.ReadOnly})
+	}
+
+	if container.Resources, err = k8sResourceRequirements(s); err != nil {
+		return nil, err
+	}
+	if container.LivenessProbe, err = k8sLivenessProbe(s); err != nil {
+		return nil, err
+	}
+	container.SecurityContext = k8sContainerSecurityContext(s)
+
+	deployment.Spec.Template.Spec.Containers = []k8sContainer{container}
+	docs = append(docs, deployment)
+	if len(service.Spec.Ports) > 0 {
+		docs = append(docs, service)
+	}
+
+	b, err := encodeYAML(docs...)
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: name + ".yaml", Data: b}}, nil
+}
+
+func isSecretEnv(name string) bool {
+	name = strings.ToUpper(name)
+	for _, s := range secretEnvNames {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// k8sHostname splits a docker hostname into the pod hostname and subdomain, which are single DNS labels.
+// Domains with more than one label are left out as the pod subdomain cannot hold them.
+func k8sHostname(hostname string) (string, string) {
+	host, domain, _ := strings.Cut(hostname, ".")
+	if strings.Contains(domain, ".") {
+		domain = ""
+	}
+	return dnsName(host), dnsName(domain)
+}
+
+// k8sAnnotations returns the docker labels and annotations of the service as pod annotations,
+// as docker label keys and values are often not valid kubernetes labels.
+// Annotations take precedence over labels with the same key.
+func k8sAnnotations(s *parser.ServiceConfig) map[string]string {
+	if len(s.Labels) == 0 && len(s.Annotations) == 0 {
+		return nil
+	}
+	annotations := make(map[string]string)
+	for _, kv := range append(append(parser.Mapping{}, s.Labels...), s.Annotations...) {
+		annotations[kv.Key] = kv.Value
+	}
+	return annotations
+}
+
+func k8sResourceRequirements(s *parser.ServiceConfig) (*k8sResources, error) {
+	if s.Deploy == nil || s.Deploy.Resources == nil {
+		return nil, nil
+	}
+	r, err := serviceResources(s)
+	if err != nil {
+		return nil, err
+	}
+	return &k8sResources{
+		Limits:   k8sResourceList(r.CPUs, r.Memory),
+		Requests: k8sResourceList(r.CPUsReservation, r.MemoryReservation),
+	}, nil
+}
+
+// k8sResourceList returns the cpu and memory quantities which are set, or nil.
+func k8sResourceList(cpus float64, memory int64) map[string]string {
+	list := make(map[string]string)
+	if cpus > 0 {
+		list["cpu"] = strconv.FormatFloat(cpus, 'f', -1, 64)
+	}
+	if memory > 0 {
+		list["memory"] = k8sQuantity(memory)
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return list
+}
+
+func k8sLivenessProbe(s *parser.ServiceConfig) (*k8sProbe, error) {
+	h := s.Healthcheck
+	if h == nil || h.Test == "" || h.Disable {
+		return nil, nil
+	}
+	d, err := parseHealthDurations(h)
+	if err != nil {
+		return nil, err
+	}
+	probe := &k8sProbe{
+		InitialDelaySeconds: int(d.StartPeriod.Seconds()),
+		PeriodSeconds:       int(d.Interval.Seconds()),
+		TimeoutSeconds:      int(d.Timeout.Seconds()),
+		FailureThreshold:    h.Retries,
+	}
+	probe.Exec.Command = []string{"/bin/sh", "-c", h.Test}
+	return probe, nil
+}
+
+func k8sContainerSecurityContext(s *parser.ServiceConfig) *k8sSecurityContext {
+	sc := &k8sSecurityContext{
+		Privileged:             s.Privileged,
+		ReadOnlyRootFilesystem: s.ReadOnly,
+	}
+	uid, gid, _ := strings.Cut(s.User, ":")
+	if v, err := strconv.ParseInt(uid, 10, 64); err == nil {
+		sc.RunAsUser = &v
+	}
+	if v, err := strconv.ParseInt(gid, 10, 64); err == nil {
+		sc.RunAsGroup = &v
+	}
+	if len(s.CapAdd) > 0 || len(s.CapDrop) > 0 {
+		sc.Capabilities = &struct {
+			Add  []string `yaml:"add,omitempty"`
+			Drop []string `yaml:"drop,omitempty"`
+		}{Add: k8sCapabilities(s.CapAdd), Drop: k8sCapabilities(s.CapDrop)}
+	}
+	if *sc == (k8sSecurityContext{}) {
+		return nil
+	}
+	return sc
+}
+
+// k8sCapabilities removes the CAP_ prefix docker accepts but kubernetes does not.
+func k8sCapabilities(caps []string) []string {
+	var result []string
+	for _, c := range caps {
+		result = append(result, strings.TrimPrefix(strings.ToUpper(c), "CAP_"))
+	}
+	return result
+}
+
+// k8sQuantity converts a size in bytes to a kubernetes quantity like 512Mi.
+func k8sQuantity(b int64) string {
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{
+		{"Ti", 1 << 40},
+		{"Gi", 1 << 30},
+		{"Mi", 1 << 20},
+		{"Ki", 1 << 10},
+	} {
+		if b >= unit.size && b%unit.size == 0 {
+			return strconv.FormatInt(b/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(b, 10)
+}
+
+// uniqueName returns name or name with a numeric suffix if it is already used.
+func uniqueName(used map[string]bool, name string) string {
+	if name == "" {
+		name = "volume"
+	}
+	unique := name
+	for i := 2; used[unique]; i++ {
+		unique = fmt.Sprintf("%s-%d", name, i)
+	}
+	used[unique] = true
+	return unique
+}
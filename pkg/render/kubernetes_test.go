@@ -0,0 +1,110 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKubernetes(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "environment split into a config map and a secret",
+			command: "docker run -e APP_ENV=prod -e DB_PASSWORD=s3cret -e github_token=t nginx",
+			want: []string{`
+kind: ConfigMap
+metadata:
+  name: nginx-config
+  labels:
+    app: nginx
+data:
+  APP_ENV: prod
+`, `
+kind: Secret
+metadata:
+  name: nginx-secret
+  labels:
+    app: nginx
+type: Opaque
+stringData:
+  DB_PASSWORD: s3cret
+  github_token: t
+`, `
+          envFrom:
+            - configMapRef:
+                name: nginx-config
+            - secretRef:
+                name: nginx-secret
+`},
+		},
+		{
+			name:    "no secret",
+			command: "docker run -e APP_ENV=prod nginx",
+			want:    []string{"kind: ConfigMap"},
+			notWant: []string{"kind: Secret", "secretRef"},
+		},
+		{
+			name:    "host environment is left out",
+			command: "docker run -e FOO -e API_KEY nginx",
+			notWant: []string{"kind: ConfigMap", "kind: Secret", "envFrom", "FOO", "API_KEY"},
+		},
+		{
+			name:    "named and anonymous volumes",
+			command: "docker run -v data:/data -v /cache nginx",
+			want: []string{`
+kind: PersistentVolumeClaim
+metadata:
+  name: data
+`, `
+      volumes:
+        - name: data
+          persistentVolumeClaim:
+            claimName: data
+        - name: cache
+          emptyDir: {}
+`},
+		},
+		{
+			name:    "port protocols",
+			command: "docker run -p 8080:80 -p 53:53/udp nginx",
+			want: []string{`
+            - containerPort: 53
+              protocol: UDP
+`, `
+    - name: udp-53
+      port: 53
+      targetPort: 53
+      protocol: UDP
+`},
+		},
+		{
+			name:    "hostname with a domain",
+			command: "docker run --hostname web.local nginx",
+			want:    []string{"      hostname: web\n      subdomain: local\n"},
+		},
+		{
+			name:    "hostname with a multi-level domain",
+			command: "docker run --hostname web.example.com nginx",
+			want:    []string{"      hostname: web\n"},
+			notWant: []string{"subdomain", "example"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "kubernetes", tt.command)
+			manifest := files["nginx.yaml"]
+			for _, want := range tt.want {
+				require.Contains(t, manifest, want)
+			}
+			for _, notWant := range tt.notWant {
+				require.NotContains(t, manifest, notWant)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// Nomad renders a nomad job specification running the container with the docker driver.
+// Published ports become network ports, volumes become docker mounts and the
+// health check becomes a script check of the task service.
+func Nomad(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	name := dnsName(s.Name)
+
+	job := &hclBlock{typ: "job", labels: []string{name}}
+	job.attr("datacenters", []string{"dc1"})
+	job.attr("type", "service")
+
+	group := job.block("group", name)
+	group.attr("count", 1)
+
+	ports, err := parsePorts(portSpecs(s.Ports))
+	if err != nil {
+		return nil, err
+	}
+	var portLabels []string
+	if len(ports) > 0 {
+		network := group.block("network")
+		for _, port := range ports {
+			label := fmt.Sprintf("p%d", port.ContainerPort)
+			if port.Protocol != "tcp" {
+				label += "-" + port.Protocol
+			}
+			if containsString(portLabels, label) {
+				continue
+			}
+			portLabels = append(portLabels, label)
+			block := network.block("port", label)
+			block.attr("static", port.HostPort)
+			block.attr("to", port.ContainerPort)
+		}
+	}
+
+	if err := nomadRestart(group.block("restart"), s.Restart); err != nil {
+		return nil, err
+	}
+
+	task := group.block("task", name)
+	task.attr("driver", "docker")
+	task.attr("user", s.User)
+	if timeout, ok, err := stopTimeout(s); err != nil {
+		return nil, err
+	} else if ok {
+		task.attr("kill_timeout", timeout.String())
+	}
+	task.attr("kill_signal", s.StopSignal)
+
+	config := task.block("config")
+	config.attr("image", s.Image)
+	if s.Entrypoint != "" {
+		config.attr("entrypoint", []string{s.Entrypoint})
+	}
+	if len(s.Command) > 0 {
+		config.attr("command", s.Command[0])
+		config.attr("args", s.Command[1:])
+	}
+	config.attr("ports", portLabels)
+	config.attr("hostname", s.Hostname)
+	config.attr("work_dir", s.WorkingDir)
+	config.attr("network_mode", s.NetworkMode)
+	if n := s.Networks["default"]; n != nil {
+		config.attr("ipv4_address", n.Ipv4Address)
+		config.attr("ipv6_address", n.Ipv6Address)
+		config.attr("network_aliases", n.Aliases)
+	}
+	config.attr("dns_servers", s.DNS)
+	config.attr("dns_search_domains", s.DNSSearch)
+	config.attr("dns_options", s.DNSOpt)
+	config.attr("extra_hosts", s.ExtraHosts)
+	config.attr("mac_address", s.MacAddress)
+	config.attr("cap_add", s.CapAdd)
+	config.attr("cap_drop", s.CapDrop)
+	config.attr("privileged", s.Privileged)
+	config.attr("readonly_rootfs", s.ReadOnly)
+	config.attr("init", s.Init)
+	config.attr("interactive", s.StdinOpen)
+	config.attr("tty", s.Tty)
+	config.attr("ipc_mode", s.Ipc)
+	config.attr("pid_mode", s.Pid)
+	config.attr("uts_mode", s.Uts)
+	config.attr("userns_mode", s.UsernsMode)
+	config.attr("security_opt", s.SecurityOpt)
+	config.attr("pids_limit", s.PidsLimit)
+	config.attr("runtime", s.Runtime)
+	if s.ShmSize != "" {
+		size, err := parseBytes(s.ShmSize)
+		if err != nil {
+			return nil, err
+		}
+		config.attr("shm_size", size)
+	}
+	// maps are attributes rather than blocks, as blocks do not accept quoted keys like "com.example.team"
+	config.attr("labels", s.Labels)
+	config.attr("sysctl", s.Sysctls)
+	var ulimits parser.Mapping
+	for _, u := range s.Ulimits {
+		ulimits.Set(u.Name, fmt.Sprintf("%d:%d", u.Soft, u.Hard))
+	}
+	config.attr("ulimit", ulimits)
+	if s.Logging != nil {
+		logging := config.block("logging")
+		logging.attr("type", s.Logging.Driver)
+		if len(s.Logging.Options) > 0 {
+			// the docker driver expects the logging options as a list of maps
+			logging.attr("config", hclExpression("["+hclValue(s.Logging.Options)+"]"))
+		}
+	}
+	for _, d := range s.Devices {
+		parts := strings.Split(d, ":")
+		device := config.block("devices")
+		device.attr("host_path", parts[0])
+		if len(parts) > 1 {
+			device.attr("container_path", parts[1])
+		}
+		if len(parts) > 2 {
+			device.attr("cgroup_permissions", parts[2])
+		}
+	}
+	for _, m := range serviceMounts(s) {
+		mount := config.block("mount")
+		mount.attr("type", m.Type)
+		mount.attr("source", m.Source)
+		mount.attr("target", m.Target)
+		mount.attr("readonly", m.ReadOnly)
+		if size := tmpfsSize(m.Options); size != "" {
+			bytes, err := parseBytes(size)
+			if err != nil {
+				return nil, err
+			}
+			mount.block("tmpfs_options").attr("size", bytes)
+		}
+	}
+
+	task.attr("env", definedEnv(s.Environment))
+
+	if err := nomadResources(task, s); err != nil {
+		return nil, err
+	}
+
+	if len(portLabels) > 0 || (s.Healthcheck != nil && s.Healthcheck.Test != "" && !s.Healthcheck.Disable) {
+		service := task.block("service")
+		service.attr("name", name)
+		if len(portLabels) > 0 {
+			service.attr("port", portLabels[0])
+		}
+		nomadCheck(service, s.Healthcheck)
+	}
+
+	return []File{{Name: name + ".nomad.hcl", Data: job.Bytes()}}, nil
+}
+
+// nomadRestart converts a docker restart policy into a nomad restart block.
+func nomadRestart(restart *hclBlock, policy string) error {
+	name, count, _ := strings.Cut(policy, ":")
+	switch name {
+	case "always", "unless-stopped":
+		restart.attr("mode", "delay")
+	case "on-failure":
+		attempts := 3
+		if count != "" {
+			var err error
+			if attempts, err = strconv.Atoi(count); err != nil {
+				return fmt.Errorf("invalid restart policy %q", policy)
+			}
+		}
+		restart.attr("attempts", attempts)
+		restart.attr("mode", "fail")
+	default:
+		restart.set("attempts", 0)
+		restart.attr("mode", "fail")
+	}
+	return nil
+}
+
+// nomadResources converts the deploy resources into a nomad resources block.
+// Nomad reserves CPU in MHz, a CPU is counted as 1000 MHz, and memory in MiB.
+func nomadResources(task *hclBlock, s *parser.ServiceConfig) error {
+	if s.Deploy == nil || s.Deploy.Resources == nil {
+		return nil
+	}
+	r, err := serviceResources(s)
+	if err != nil {
+		return err
+	}
+
+	resources := task.block("resources")
+	cpus := r.CPUsReservation
+	if cpus == 0 {
+		cpus = r.CPUs
+	}
+	resources.attr("cpu", int(math.Round(cpus*1000)))
+
+	memory, memoryMax := r.MemoryReservation, r.Memory
+	if memory == 0 {
+		memory, memoryMax = r.Memory, 0
+	}
+	resources.attr("memory", int(memory>>20))
+	resources.attr("memory_max", int(memoryMax>>20))
+	return nil
+}
+
+// nomadCheck converts the health check into a script check.
+func nomadCheck(service *hclBlock, h *parser.HealthCheckConfig) {
+	if h == nil || h.Test == "" || h.Disable {
+		return
+	}
+	check := service.block("check")
+	check.attr("type", "script")
+	check.attr("command", "/bin/sh")
+	check.attr("args", []string{"-c", h.Test})
+	interval, timeout := h.Interval, h.Timeout
+	if interval == "" {
+		interval = "30s"
+	}
+	if timeout == "" {
+		timeout = "30s"
+	}
+	check.attr("interval", interval)
+	check.attr("timeout", timeout)
+
+	if h.Retries > 0 || h.StartPeriod != "" {
+		checkRestart := check.block("check_restart")
+		checkRestart.attr("limit", h.Retries)
+		checkRestart.attr("grace", h.StartPeriod)
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
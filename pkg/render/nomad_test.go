@@ -0,0 +1,85 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNomad(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+	}{
+		{
+			name:    "maps are attributes with quoted keys",
+			command: "docker run -l com.example.team=web -l tier=frontend --sysctl net.core.somaxconn=1024 -e app.mode=prod nginx",
+			want: []string{
+				`
+        labels = {
+          "com.example.team" = "web"
+          tier               = "frontend"
+        }
+        sysctl = { "net.core.somaxconn" = "1024" }
+`,
+				`
+      env = { "app.mode" = "prod" }
+`,
+			},
+		},
+		{
+			name:    "logging options are a list of maps",
+			command: "docker run --log-driver json-file --log-opt max-size=10m --log-opt max-file=3 nginx",
+			want: []string{`
+        logging {
+          type   = "json-file"
+          config = [{
+            max-size = "10m"
+            max-file = "3"
+          }]
+        }
+`},
+		},
+		{
+			name:    "ulimits",
+			command: "docker run --ulimit nofile=1024:2048 --ulimit nproc=512:1024 nginx",
+			want: []string{`
+        ulimit = {
+          nofile = "1024:2048"
+          nproc  = "512:1024"
+        }
+`},
+		},
+		{
+			name:    "restart on failure with attempts",
+			command: "docker run --restart on-failure:5 nginx",
+			want: []string{`
+    restart {
+      attempts = 5
+      mode     = "fail"
+    }
+`},
+		},
+		{
+			name:    "no restart",
+			command: "docker run nginx",
+			want: []string{`
+    restart {
+      attempts = 0
+      mode     = "fail"
+    }
+`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "nomad", tt.command)
+			job := files["nginx.nomad.hcl"]
+			for _, want := range tt.want {
+				require.Contains(t, job, want)
+			}
+		})
+	}
+}
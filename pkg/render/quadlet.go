@@ -0,0 +1,280 @@
+package render
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// podmanNetworkModes are the network modes which are not the name of a user defined network.
+var podmanNetworkModes = []string{"bridge", "default", "host", "none", "private", "slirp4netns", "pasta"}
+
+// Quadlet renders a podman quadlet .container unit with a .volume unit for each named volume
+// and a .network unit for each user defined network.
+// Flags without a quadlet key are passed with PodmanArgs=.
+func Quadlet(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+
+	unit := &unitFile{}
+	unit.section("Unit").add("Description", s.Name+" container")
+
+	c := unit.section("Container")
+	c.add("ContainerName", s.ContainerName)
+	c.add("Image", s.Image)
+	if len(s.Command) > 0 {
+		c.add("Exec", unitArgs(s.Command))
+	}
+	c.add("Entrypoint", s.Entrypoint)
+	c.add("HostName", s.Hostname)
+	user, group, _ := strings.Cut(s.User, ":")
+	c.add("User", user)
+	c.add("Group", group)
+	c.add("GroupAdd", s.GroupAdd...)
+	c.add("WorkingDir", s.WorkingDir)
+
+	for _, kv := range definedEnv(s.Environment) {
+		c.add("Environment", unitQuote(kv.Key+"="+kv.Value))
+	}
+	c.add("EnvironmentFile", s.EnvFile...)
+
+	c.add("PublishPort", portSpecs(s.Ports)...)
+	c.add("ExposeHostPort", s.Expose...)
+
+	var volumes, networks []string
+	for _, v := range s.Volumes {
+		if v.Mount != nil {
+			c.add("Mount", quadletMount(v.Mount, &volumes))
+			continue
+		}
+		parts := strings.SplitN(v.Spec, ":", 2)
+		if len(parts) == 2 && !isHostPath(parts[0]) {
+			volumes = appendUnique(volumes, parts[0])
+			parts[0] += ".volume"
+		}
+		c.add("Volume", strings.Join(parts, ":"))
+	}
+	c.add("Tmpfs", s.Tmpfs...)
+
+	if mode := s.NetworkMode; mode != "" {
+		if isPodmanNetworkMode(mode) {
+			c.add("Network", mode)
+		} else {
+			networks = appendUnique(networks, mode)
+			c.add("Network", mode+".network")
+		}
+	}
+	for _, name := range sortedNetworks(s.Networks) {
+		if name != "default" {
+			networks = appendUnique(networks, name)
+			c.add("Network", name+".network")
+		}
+		n := s.Networks[name]
+		if n == nil {
+			continue
+		}
+		c.add("IP", n.Ipv4Address)
+		c.add("IP6", n.Ipv6Address)
+		c.add("NetworkAlias", n.Aliases...)
+	}
+	c.add("DNS", s.DNS...)
+	c.add("DNSSearch", s.DNSSearch...)
+	c.add("DNSOption", s.DNSOpt...)
+	c.add("AddHost", s.ExtraHosts...)
+	c.add("AddDevice", s.Devices...)
+
+	c.add("AddCapability", s.CapAdd...)
+	c.add("DropCapability", s.CapDrop...)
+	if s.ReadOnly {
+		c.add("ReadOnly", "true")
+	}
+	if s.Init {
+		c.add("RunInit", "true")
+	}
+	var podmanArgs []string
+	for _, opt := range s.SecurityOpt {
+		switch {
+		case opt == "label=disable" || opt == "label:disable":
+			c.add("SecurityLabelDisable", "true")
+		case opt == "no-new-privileges" || opt == "no-new-privileges:true" || opt == "no-new-privileges=true":
+			c.add("NoNewPrivileges", "true")
+		case strings.HasPrefix(opt, "seccomp=") || strings.HasPrefix(opt, "seccomp:"):
+			c.add("SeccompProfile", opt[len("seccomp="):])
+		default:
+			podmanArgs = append(podmanArgs, "--security-opt="+opt)
+		}
+	}
+	c.add("UserNS", s.UsernsMode)
+
+	for _, kv := range s.Labels {
+		c.add("Label", unitQuote(kv.Key+"="+kv.Value))
+	}
+	for _, kv := range s.Annotations {
+		c.add("Annotation", unitQuote(kv.Key+"="+kv.Value))
+	}
+	if s.Logging != nil {
+		c.add("LogDriver", s.Logging.Driver)
+		for _, kv := range s.Logging.Options {
+			c.add("LogOpt", kv.Key+"="+kv.Value)
+		}
+	}
+	for _, u := range s.Ulimits {
+		c.add("Ulimit", fmt.Sprintf("%s=%d:%d", u.Name, u.Soft, u.Hard))
+	}
+	for _, kv := range s.Sysctls {
+		c.add("Sysctl", kv.Key+"="+kv.Value)
+	}
+	c.add("ShmSize", s.ShmSize)
+	if s.PidsLimit != 0 {
+		c.add("PidsLimit", strconv.FormatInt(s.PidsLimit, 10))
+	}
+
+	if h := s.Healthcheck; h != nil {
+		if h.Disable {
+			c.add("HealthCmd", "none")
+		} else {
+			c.add("HealthCmd", h.Test)
+			c.add("HealthInterval", h.Interval)
+			c.add("HealthTimeout", h.Timeout)
+			if h.Retries > 0 {
+				c.add("HealthRetries", strconv.FormatInt(h.Retries, 10))
+			}
+			c.add("HealthStartPeriod", h.StartPeriod)
+		}
+	}
+
+	if timeout, ok, err := stopTimeout(s); err != nil {
+		return nil, err
+	} else if ok {
+		c.add("StopTimeout", strconv.Itoa(int(timeout.Seconds())))
+	}
+	c.add("StopSignal", s.StopSignal)
+
+	c.add("PodmanArgs", append(quadletPodmanArgs(s), podmanArgs...)...)
+
+	restart := systemdRestart(s.Restart)
+	unit.section("Service").add("Restart", restart)
+	if restart == "always" {
+		unit.section("Install").add("WantedBy", "default.target")
+	}
+
+	files := []File{{Name: s.Name + ".container", Data: unit.Bytes()}}
+	for _, name := range volumes {
+		volume := &unitFile{}
+		volume.section("Volume").add("VolumeName", name)
+		files = append(files, File{Name: name + ".volume", Data: volume.Bytes()})
+	}
+	for _, name := range networks {
+		network := &unitFile{}
+		network.section("Network").add("NetworkName", name)
+		files = append(files, File{Name: name + ".network", Data: network.Bytes()})
+	}
+	return files, nil
+}
+
+// quadletMount converts a --mount value into the quadlet Mount= syntax.
+// Named volumes refer to their .volume unit and are added to volumes.
+func quadletMount(m *parser.Mount, volumes *[]string) string {
+	mountType := m.Type
+	if mountType == "" {
+		mountType = volumeMount
+	}
+	opts := []string{"type=" + mountType}
+	if source := m.Source; source != "" {
+		if mountType == volumeMount {
+			*volumes = appendUnique(*volumes, source)
+			source += ".volume"
+		}
+		opts = append(opts, "source="+source)
+	}
+	opts = append(opts, "destination="+m.Target)
+	if m.Readonly == "true" || m.Readonly == "1" {
+		opts = append(opts, "readonly=true")
+	}
+	if m.BindPropagation != "" {
+		opts = append(opts, "bind-propagation="+m.BindPropagation)
+	}
+	if m.TmpfsSize != "" {
+		opts = append(opts, "tmpfs-size="+m.TmpfsSize)
+	}
+	return strings.Join(opts, ",")
+}
+
+// quadletPodmanArgs returns the podman run flags of the service which have no quadlet key.
+func quadletPodmanArgs(s *parser.ServiceConfig) []string {
+	var args []string
+	flag := func(name, value string) {
+		if value != "" {
+			args = append(args, "--"+name+"="+value)
+		}
+	}
+	if s.Privileged {
+		args = append(args, "--privileged")
+	}
+	if s.Tty {
+		args = append(args, "--tty")
+	}
+	if s.StdinOpen {
+		args = append(args, "--interactive")
+	}
+	if s.Deploy != nil && s.Deploy.Resources != nil {
+		if limits := s.Deploy.Resources.Limits; limits != nil {
+			if limits.CPUs > 0 {
+				flag("cpus", strconv.FormatFloat(limits.CPUs, 'f', -1, 64))
+			}
+			flag("memory", limits.Memory)
+		}
+		if reservations := s.Deploy.Resources.Reservations; reservations != nil {
+			flag("memory-reservation", reservations.Memory)
+		}
+	}
+	flag("memory-swap", s.MemswapLimit)
+	if s.CPUShares != 0 {
+		flag("cpu-shares", strconv.FormatInt(s.CPUShares, 10))
+	}
+	flag("cpuset-cpus", s.Cpuset)
+	flag("cpuset-mems", s.CpusetMems)
+	flag("cgroup-parent", s.CgroupParent)
+	if s.OomScoreAdj != 0 {
+		flag("oom-score-adj", strconv.FormatInt(s.OomScoreAdj, 10))
+	}
+	if s.OomKillDisable {
+		args = append(args, "--oom-kill-disable")
+	}
+	flag("pid", s.Pid)
+	flag("ipc", s.Ipc)
+	flag("uts", s.Uts)
+	flag("mac-address", s.MacAddress)
+	flag("platform", s.Platform)
+	flag("runtime", s.Runtime)
+	return args
+}
+
+func isPodmanNetworkMode(mode string) bool {
+	for _, m := range podmanNetworkModes {
+		if mode == m {
+			return true
+		}
+	}
+	return strings.HasPrefix(mode, "container:") || strings.HasPrefix(mode, "ns:") ||
+		strings.HasPrefix(mode, "slirp4netns:") || strings.HasPrefix(mode, "pasta:")
+}
+
+// sortedNetworks returns the network names of a service in a stable order.
+func sortedNetworks(networks map[string]*parser.ServiceNetworkConfig) []string {
+	names := make([]string, 0, len(networks))
+	for name := range networks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func appendUnique(list []string, s string) []string {
+	if containsString(list, s) {
+		return list
+	}
+	return append(list, s)
+}
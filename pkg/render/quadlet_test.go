@@ -0,0 +1,83 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuadlet(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		want      []string
+		notWant   []string
+		wantFiles map[string]string
+	}{
+		{
+			name:    "named volumes have a volume unit",
+			command: "docker run -v data:/data -v data:/more --mount type=volume,src=cache,dst=/cache nginx",
+			want: []string{
+				"Volume=data.volume:/data\n",
+				"Volume=data.volume:/more\n",
+				"Mount=type=volume,source=cache.volume,destination=/cache\n",
+			},
+			wantFiles: map[string]string{
+				"data.volume":  "[Volume]\nVolumeName=data\n",
+				"cache.volume": "[Volume]\nVolumeName=cache\n",
+			},
+		},
+		{
+			name:    "host paths and anonymous volumes",
+			command: "docker run -v /srv:/srv -v /anon --mount type=bind,src=/etc/app,dst=/etc/app,readonly nginx",
+			want: []string{
+				"Volume=/srv:/srv\n",
+				"Volume=/anon\n",
+				"Mount=type=bind,source=/etc/app,destination=/etc/app,readonly=true\n",
+			},
+			notWant: []string{".volume"},
+		},
+		{
+			name:    "user defined network has a network unit",
+			command: "docker run --network backend --network-alias web nginx",
+			want:    []string{"Network=backend.network\n", "NetworkAlias=web\n"},
+			wantFiles: map[string]string{
+				"backend.network": "[Network]\nNetworkName=backend\n",
+			},
+		},
+		{
+			name:    "podman network modes",
+			command: "docker run --network host nginx",
+			want:    []string{"Network=host\n"},
+			notWant: []string{".network"},
+		},
+		{
+			name:    "restart always is enabled at boot",
+			command: "docker run --restart always nginx",
+			want:    []string{"[Service]\nRestart=always\n", "[Install]\nWantedBy=default.target\n"},
+		},
+		{
+			name:    "restart on failure",
+			command: "docker run --restart on-failure:3 nginx",
+			want:    []string{"[Service]\nRestart=on-failure\n"},
+			notWant: []string{"[Install]"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "quadlet", tt.command)
+			require.Len(t, files, len(tt.wantFiles)+1)
+			container := files["nginx.container"]
+			for _, want := range tt.want {
+				require.Contains(t, container, want)
+			}
+			for _, notWant := range tt.notWant {
+				require.NotContains(t, container, notWant)
+			}
+			for name, want := range tt.wantFiles {
+				require.Equal(t, want, files[name], name)
+			}
+		})
+	}
+}
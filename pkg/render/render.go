@@ -0,0 +1,99 @@
+// Package render renders a parsed docker run command into output formats other than docker compose.
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// DefaultFormat is the docker compose output format.
+const DefaultFormat = "compose"
+
+// File is a file produced by a Renderer.
+type File struct {
+	// Name is the suggested file name.
+	Name string
+	Data []byte
+}
+
+// Renderer renders a parsed docker run command into one or more files.
+type Renderer func(p *parser.Parser) ([]File, error)
+
+var renderers = map[string]Renderer{
+	DefaultFormat:       Compose,
+	"ansible":           Ansible,
+	"devcontainer":      DevContainer,
+	"ecs":               ECS,
+	"engine-json":       EngineJSON,
+	"github-actions":    GitHubActions,
+	"gitlab-ci":         GitLabCI,
+	"kubernetes":        Kubernetes,
+	"nomad":             Nomad,
+	"quadlet":           Quadlet,
+	"systemd":           Systemd,
+	"terraform":         Terraform,
+	"testcontainers-go": TestcontainersGo,
+}
+
+// Formats returns the names of the supported output formats.
+func Formats() []string {
+	formats := make([]string, 0, len(renderers))
+	for name := range renderers {
+		formats = append(formats, name)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// Render renders the parsed docker run command into the given format.
+// Parse must be called on the parser before rendering.
+func Render(format string, p *parser.Parser) ([]File, error) {
+	renderer, ok := renderers[format]
+	if !ok {
+		return nil, fmt.Errorf("unknown output format %q. Supported formats are %v", format, Formats())
+	}
+	return renderer(p)
+}
+
+// Compose renders the docker compose file.
+func Compose(p *parser.Parser) ([]File, error) {
+	b, err := p.Render()
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: "compose.yml", Data: b}}, nil
+}
+
+// Concat joins the files into a single output. When there are several files,
+// each file is preceded by a comment line naming it, eg: # web.container.
+func Concat(files []File) []byte {
+	var buf bytes.Buffer
+	for _, file := range files {
+		if len(files) > 1 {
+			fmt.Fprintf(&buf, "# %s\n", file.Name)
+		}
+		buf.Write(file.Data)
+	}
+	return buf.Bytes()
+}
+
+// encodeYAML encodes documents into a multi-document yaml stream using two spaces of indentation.
+func encodeYAML(docs ...interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	for _, doc := range docs {
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
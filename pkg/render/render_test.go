@@ -0,0 +1,182 @@
+package render
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+// renderCommands are the docker run commands rendered into every format.
+var renderCommands = map[string]string{
+	"minimal": "docker run redis",
+	"values":  "docker run -e PORT=8080 -e DEBUG=true -e RATIO=1.5 -e N=08 -l version=2 redis",
+	"full": `docker run -d --name web -p 8080:80 -p 127.0.0.1:8443:443/tcp --expose 9000 \
+-e APP_ENV=production -e DB_PASSWORD=secret \
+-v data:/var/lib/data -v /etc/app:/etc/app:ro --tmpfs /run:size=64m \
+--cpus 0.5 --memory 512m --memory-reservation 256m \
+--health-cmd "curl -f http://localhost/ || exit 1" --health-interval 30s --health-timeout 5s --health-retries 3 \
+--restart unless-stopped -u 1000:1000 -w /app --cap-add NET_ADMIN --cap-drop ALL \
+-l com.example.team=web --log-driver json-file --log-opt max-size=10m --ulimit nofile=1024:2048 \
+--network backend --hostname web.local --privileged=false \
+--entrypoint /docker-entrypoint.sh nginx:1.25 nginx -g "daemon off;"`,
+}
+
+func TestRender(t *testing.T) {
+	for _, format := range Formats() {
+		for name, command := range renderCommands {
+			t.Run(format+"/"+name, func(t *testing.T) {
+				p, err := parser.New(command)
+				require.NoError(t, err)
+				require.NoError(t, p.Parse())
+
+				files, err := Render(format, p)
+				require.NoError(t, err)
+				require.NotEmpty(t, files)
+
+				dir := filepath.Join("testdata", format, name)
+				if *update {
+					require.NoError(t, os.RemoveAll(dir))
+				}
+
+				var names []string
+				for _, file := range files {
+					golden := filepath.Join(dir, file.Name)
+					names = append(names, file.Name)
+					if *update {
+						require.NoError(t, os.MkdirAll(filepath.Dir(golden), 0o755))
+						require.NoError(t, os.WriteFile(golden, file.Data, 0o644))
+						continue
+					}
+					want, err := os.ReadFile(golden)
+					require.NoError(t, err)
+					require.Equal(t, string(want), string(file.Data), file.Name)
+				}
+
+				var goldenFiles []string
+				err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+					if err != nil || info.IsDir() {
+						return err
+					}
+					rel, err := filepath.Rel(dir, path)
+					goldenFiles = append(goldenFiles, filepath.ToSlash(rel))
+					return err
+				})
+				require.NoError(t, err)
+				require.ElementsMatch(t, goldenFiles, names)
+			})
+		}
+	}
+}
+
+func TestRenderUnknownFormat(t *testing.T) {
+	p, err := parser.New("docker run redis")
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+
+	_, err = Render("swarm", p)
+	require.ErrorContains(t, err, `unknown output format "swarm"`)
+}
+
+func TestParsePorts(t *testing.T) {
+	ports, err := parsePorts([]string{"80", "8080:80", "127.0.0.1:53:53/udp", "[::1]:9000-9001:9000-9001"})
+	require.NoError(t, err)
+	require.Equal(t, []portMapping{
+		{ContainerPort: 80, Protocol: "tcp", Published: true},
+		{HostPort: 8080, ContainerPort: 80, Protocol: "tcp", Published: true},
+		{HostIP: "127.0.0.1", HostPort: 53, ContainerPort: 53, Protocol: "udp", Published: true},
+		{HostIP: "::1", HostPort: 9000, ContainerPort: 9000, Protocol: "tcp", Published: true},
+		{HostIP: "::1", HostPort: 9001, ContainerPort: 9001, Protocol: "tcp", Published: true},
+	}, ports)
+
+	_, err = parsePorts([]string{"8080-8081:80"})
+	require.Error(t, err)
+}
+
+func TestParseBytes(t *testing.T) {
+	for s, want := range map[string]int64{
+		"512":   512,
+		"64k":   64 << 10,
+		"512m":  512 << 20,
+		"1.5g":  3 << 29,
+		"2GB":   2 << 30,
+		"100mb": 100 << 20,
+	} {
+		got, err := parseBytes(s)
+		require.NoError(t, err, s)
+		require.Equal(t, want, got, s)
+	}
+
+	_, err := parseBytes("lots")
+	require.Error(t, err)
+}
+
+// renderFiles renders the docker run command into the format and returns the contents of the files by name.
+func renderFiles(t *testing.T, format, command string) map[string]string {
+	t.Helper()
+	p, err := parser.New(command)
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+
+	files, err := Render(format, p)
+	require.NoError(t, err)
+	contents := make(map[string]string, len(files))
+	for _, file := range files {
+		contents[file.Name] = string(file.Data)
+	}
+	return contents
+}
+
+func TestEnvList(t *testing.T) {
+	env := parser.Mapping{{Key: "APP_ENV", Value: "prod"}, {Key: "FOO"}, {Key: "GREETING", Value: "hello world"}}
+	require.Equal(t, []string{"APP_ENV=prod", "GREETING=hello world"}, envList(env))
+	require.Empty(t, envList(parser.Mapping{{Key: "FOO"}}))
+}
+
+func TestServiceSettings(t *testing.T) {
+	p, err := parser.New("docker run --stop-timeout 20 --cpus 1.5 --memory 1g --memory-reservation 512m --memory-swap=-1 " +
+		"--health-interval 30s --health-start-period 1m nginx")
+	require.NoError(t, err)
+	require.NoError(t, p.Parse())
+	s := p.Service()
+
+	timeout, ok, err := stopTimeout(s)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, 20*time.Second, timeout)
+
+	r, err := serviceResources(s)
+	require.NoError(t, err)
+	require.Equal(t, resources{CPUs: 1.5, Memory: 1 << 30, MemoryReservation: 512 << 20, MemorySwap: -1}, r)
+
+	d, err := parseHealthDurations(s.Healthcheck)
+	require.NoError(t, err)
+	require.Equal(t, healthDurations{Interval: 30 * time.Second, StartPeriod: time.Minute}, d)
+
+	_, ok, err = stopTimeout(&parser.ServiceConfig{})
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, _, err = stopTimeout(&parser.ServiceConfig{StopGracePeriod: "soon"})
+	require.ErrorContains(t, err, `invalid stop timeout "soon"`)
+
+	_, err = serviceResources(&parser.ServiceConfig{MemswapLimit: "lots"})
+	require.EqualError(t, err, `invalid size "lots"`)
+
+	_, err = parseHealthDurations(&parser.HealthCheckConfig{Timeout: "5 seconds"})
+	require.ErrorContains(t, err, `invalid health check duration "5 seconds"`)
+}
+
+func TestConcat(t *testing.T) {
+	web := File{Name: "web.container", Data: []byte("[Container]\n")}
+	data := File{Name: "data.volume", Data: []byte("[Volume]\n")}
+	require.Equal(t, "[Container]\n", string(Concat([]File{web})))
+	require.Equal(t, "# web.container\n[Container]\n# data.volume\n[Volume]\n", string(Concat([]File{web, data})))
+}
@@ -0,0 +1,426 @@
+package render
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// portMapping is a port of a service, published with -p or exposed with --expose.
+type portMapping struct {
+	HostIP string
+	// HostPort is 0 when the port is published on a random host port or not published at all.
+	HostPort      int
+	ContainerPort int
+	// Protocol is tcp, udp or sctp.
+	Protocol  string
+	Published bool
+}
+
+// parsePorts parses docker run -p values in the [IP:][HOST_PORT:]CONTAINER_PORT[/PROTOCOL] format.
+// Port ranges are expanded into a mapping per port.
+func parsePorts(specs []string) ([]portMapping, error) {
+	var mappings []portMapping
+	for _, spec := range specs {
+		rest, protocol, _ := strings.Cut(spec, "/")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+
+		var hostIP string
+		if strings.HasPrefix(rest, "[") {
+			// IPv6 host IP, eg: [::1]:8080:80
+			end := strings.Index(rest, "]:")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid port %q", spec)
+			}
+			hostIP, rest = rest[1:end], rest[end+2:]
+		}
+
+		var hostPorts, containerPorts string
+		parts := strings.Split(rest, ":")
+		switch len(parts) {
+		case 1:
+			containerPorts = parts[0]
+		case 2:
+			hostPorts, containerPorts = parts[0], parts[1]
+		case 3:
+			if hostIP != "" {
+				return nil, fmt.Errorf("invalid port %q", spec)
+			}
+			hostIP, hostPorts, containerPorts = parts[0], parts[1], parts[2]
+		default:
+			return nil, fmt.Errorf("invalid port %q", spec)
+		}
+
+		container, err := parsePortRange(containerPorts)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %w", spec, err)
+		}
+
+		host := make([]int, len(container))
+		if hostPorts != "" {
+			host, err = parsePortRange(hostPorts)
+			if err != nil {
+				return nil, fmt.Errorf("invalid port %q: %w", spec, err)
+			}
+			if len(host) != len(container) {
+				return nil, fmt.Errorf("invalid port %q: host and container port ranges do not match", spec)
+			}
+		}
+
+		for i := range container {
+			mappings = append(mappings, portMapping{
+				HostIP:        hostIP,
+				HostPort:      host[i],
+				ContainerPort: container[i],
+				Protocol:      strings.ToLower(protocol),
+				Published:     true,
+			})
+		}
+	}
+	return mappings, nil
+}
+
+// parseExpose parses docker run --expose values in the PORT[-PORT][/PROTOCOL] format.
+func parseExpose(specs []string) ([]portMapping, error) {
+	var mappings []portMapping
+	for _, spec := range specs {
+		ports, protocol, _ := strings.Cut(spec, "/")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		container, err := parsePortRange(ports)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %w", spec, err)
+		}
+		for _, port := range container {
+			mappings = append(mappings, portMapping{
+				ContainerPort: port,
+				Protocol:      strings.ToLower(protocol),
+			})
+		}
+	}
+	return mappings, nil
+}
+
+// servicePorts returns the published and exposed ports of a service.
+// Exposed ports which are also published are left out.
+func servicePorts(s *parser.ServiceConfig) ([]portMapping, error) {
+	published, err := parsePorts(portSpecs(s.Ports))
+	if err != nil {
+		return nil, err
+	}
+	exposed, err := parseExpose(s.Expose)
+	if err != nil {
+		return nil, err
+	}
+
+	ports := published
+	for _, e := range exposed {
+		found := false
+		for _, p := range published {
+			if p.ContainerPort == e.ContainerPort && p.Protocol == e.Protocol {
+				found = true
+				break
+			}
+		}
+		if !found {
+			ports = append(ports, e)
+		}
+	}
+	return ports, nil
+}
+
+func parsePortRange(s string) ([]int, error) {
+	start, end, isRange := strings.Cut(s, "-")
+	first, err := strconv.Atoi(start)
+	if err != nil {
+		return nil, err
+	}
+	last := first
+	if isRange {
+		last, err = strconv.Atoi(end)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if first < 0 || last < first || last > math.MaxUint16 {
+		return nil, fmt.Errorf("invalid port range %q", s)
+	}
+
+	ports := make([]int, 0, last-first+1)
+	for port := first; port <= last; port++ {
+		ports = append(ports, port)
+	}
+	return ports, nil
+}
+
+// Volume types
+const (
+	bindMount   = "bind"
+	volumeMount = "volume"
+	tmpfsMount  = "tmpfs"
+)
+
+// mount is a bind mount, volume or tmpfs mounted into a service.
+type mount struct {
+	Type string
+	// Source is the host path of a bind mount or the volume name.
+	// It is empty for anonymous volumes and tmpfs mounts.
+	Source   string
+	Target   string
+	ReadOnly bool
+	// Options holds the tmpfs options, eg: size=64m.
+	Options string
+}
+
+// serviceMounts returns the volumes, mounts and tmpfs mounts of a service.
+func serviceMounts(s *parser.ServiceConfig) []mount {
+	var mounts []mount
+	for _, v := range s.Volumes {
+		if v.Mount != nil {
+			m := mount{
+				Type:     v.Mount.Type,
+				Source:   v.Mount.Source,
+				Target:   v.Mount.Target,
+				ReadOnly: v.Mount.Readonly == "true" || v.Mount.Readonly == "1",
+			}
+			if m.Type == "" {
+				m.Type = volumeMount
+			}
+			if m.Type == tmpfsMount && v.Mount.TmpfsSize != "" {
+				m.Options = "size=" + v.Mount.TmpfsSize
+			}
+			mounts = append(mounts, m)
+			continue
+		}
+
+		parts := strings.Split(v.Spec, ":")
+		m := mount{Type: volumeMount, Target: parts[0]}
+		if len(parts) > 1 {
+			m.Source, m.Target = parts[0], parts[1]
+			if isHostPath(m.Source) {
+				m.Type = bindMount
+			}
+		}
+		if len(parts) > 2 {
+			for _, opt := range strings.Split(parts[2], ",") {
+				if opt == "ro" {
+					m.ReadOnly = true
+				}
+			}
+		}
+		mounts = append(mounts, m)
+	}
+
+	for _, t := range s.Tmpfs {
+		target, options, _ := strings.Cut(t, ":")
+		mounts = append(mounts, mount{Type: tmpfsMount, Target: target, Options: options})
+	}
+	return mounts
+}
+
+// isHostPath reports whether the source of a -v value is a host path rather than a volume name.
+func isHostPath(source string) bool {
+	return strings.ContainsAny(source, `/\`) || strings.HasPrefix(source, ".") || strings.HasPrefix(source, "~")
+}
+
+// tmpfsSize returns the size option of tmpfs mount options.
+func tmpfsSize(options string) string {
+	for _, opt := range strings.Split(options, ",") {
+		if strings.HasPrefix(opt, "size=") {
+			return strings.TrimPrefix(opt, "size=")
+		}
+	}
+	return ""
+}
+
+// parseBytes parses a docker memory size like 512m or 1g into bytes.
+func parseBytes(s string) (int64, error) {
+	size := strings.ToLower(strings.TrimSpace(s))
+	size = strings.TrimSuffix(size, "b")
+
+	multiplier := int64(1)
+	if size != "" {
+		switch size[len(size)-1] {
+		case 'k':
+			multiplier = 1 << 10
+		case 'm':
+			multiplier = 1 << 20
+		case 'g':
+			multiplier = 1 << 30
+		case 't':
+			multiplier = 1 << 40
+		case 'p':
+			multiplier = 1 << 50
+		}
+		if multiplier > 1 {
+			size = size[:len(size)-1]
+		}
+	}
+
+	v, err := strconv.ParseFloat(size, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(v * float64(multiplier)), nil
+}
+
+// parseDuration parses a docker duration like 30s or 1m30s.
+// Plain numbers, as used by --stop-timeout, are seconds.
+func parseDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.Atoi(s); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// stopTimeout returns the stop grace period of the service. ok is false when it is not set.
+func stopTimeout(s *parser.ServiceConfig) (timeout time.Duration, ok bool, err error) {
+	if s.StopGracePeriod == "" {
+		return 0, false, nil
+	}
+	if timeout, err = parseDuration(s.StopGracePeriod); err != nil {
+		return 0, false, fmt.Errorf("invalid stop timeout %q: %w", s.StopGracePeriod, err)
+	}
+	return timeout, true, nil
+}
+
+// resources are the resource limits and reservations of a service with the sizes in bytes.
+// Values which are not set are zero.
+type resources struct {
+	CPUs              float64
+	CPUsReservation   float64
+	Memory            int64
+	MemoryReservation int64
+	// MemorySwap is -1 for unlimited swap.
+	MemorySwap int64
+}
+
+// serviceResources returns the deploy resources and the memory swap limit of the service.
+func serviceResources(s *parser.ServiceConfig) (resources, error) {
+	var r resources
+	var err error
+	switch s.MemswapLimit {
+	case "":
+	case "-1":
+		r.MemorySwap = -1
+	default:
+		if r.MemorySwap, err = parseBytes(s.MemswapLimit); err != nil {
+			return r, err
+		}
+	}
+	if s.Deploy == nil || s.Deploy.Resources == nil {
+		return r, nil
+	}
+	if limits := s.Deploy.Resources.Limits; limits != nil {
+		r.CPUs = limits.CPUs
+		if limits.Memory != "" {
+			if r.Memory, err = parseBytes(limits.Memory); err != nil {
+				return r, err
+			}
+		}
+	}
+	if reservations := s.Deploy.Resources.Reservations; reservations != nil {
+		r.CPUsReservation = reservations.CPUs
+		if reservations.Memory != "" {
+			if r.MemoryReservation, err = parseBytes(reservations.Memory); err != nil {
+				return r, err
+			}
+		}
+	}
+	return r, nil
+}
+
+// healthDurations are the durations of a health check. Durations which are not set are zero.
+type healthDurations struct {
+	Interval    time.Duration
+	Timeout     time.Duration
+	StartPeriod time.Duration
+}
+
+// parseHealthDurations parses the interval, timeout and start period of a health check.
+func parseHealthDurations(h *parser.HealthCheckConfig) (healthDurations, error) {
+	var d healthDurations
+	for _, v := range []struct {
+		value  string
+		target *time.Duration
+	}{
+		{h.Interval, &d.Interval},
+		{h.Timeout, &d.Timeout},
+		{h.StartPeriod, &d.StartPeriod},
+	} {
+		if v.value == "" {
+			continue
+		}
+		duration, err := parseDuration(v.value)
+		if err != nil {
+			return d, fmt.Errorf("invalid health check duration %q: %w", v.value, err)
+		}
+		*v.target = duration
+	}
+	return d, nil
+}
+
+// dnsName converts a name to a DNS label as required for kubernetes and nomad names.
+func dnsName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('-')
+		}
+	}
+	s := strings.Trim(b.String(), "-")
+	if len(s) > 63 {
+		s = strings.TrimRight(s[:63], "-")
+	}
+	return s
+}
+
+// healthTest returns the health check command in the CMD-SHELL form used by docker.
+func healthTest(s *parser.ServiceConfig) []string {
+	if s.Healthcheck == nil || s.Healthcheck.Test == "" {
+		return nil
+	}
+	return []string{"CMD-SHELL", s.Healthcheck.Test}
+}
+
+// portSpecs returns the published ports of a service in the short syntax.
+func portSpecs(ports []parser.ServicePort) []string {
+	var specs []string
+	for _, port := range ports {
+		specs = append(specs, port.String())
+	}
+	return specs
+}
+
+// envList returns the environment variables with a value as KEY=VALUE items.
+func envList(env parser.Mapping) []string {
+	env = definedEnv(env)
+	list := make([]string, 0, len(env))
+	for _, kv := range env {
+		list = append(list, kv.Key+"="+kv.Value)
+	}
+	return list
+}
+
+// definedEnv returns the environment variables with a value. The variables passed through from
+// the environment of docker run, eg: -e FOO, are left out as there is no such environment when
+// the container is not started by docker run on the host.
+func definedEnv(env parser.Mapping) parser.Mapping {
+	var defined parser.Mapping
+	for _, kv := range env {
+		if kv.Value != "" {
+			defined = append(defined, kv)
+		}
+	}
+	return defined
+}
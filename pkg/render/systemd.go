@@ -0,0 +1,57 @@
+package render
+
+import (
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// systemdDroppedFlags are docker run flags which are not passed to docker run in the systemd unit.
+// The container runs in the foreground without a terminal and systemd restarts it
+// instead of the docker restart policy. The container name is always set by the unit.
+var systemdDroppedFlags = map[string]bool{
+	"detach":      true,
+	"interactive": true,
+	"tty":         true,
+	"restart":     true,
+	"name":        true,
+}
+
+// dockerBinary is the path of the docker CLI used in the systemd unit.
+const dockerBinary = "/usr/bin/docker"
+
+// Systemd renders a systemd .service unit which runs the normalised docker run command.
+// Any container left over with the same name is removed before the container starts.
+func Systemd(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	name := s.ContainerName
+	if name == "" {
+		name = s.Name
+	}
+
+	args := []string{dockerBinary, "run", "--name=" + name}
+	for _, f := range p.Flags() {
+		if !systemdDroppedFlags[f.Name] {
+			args = append(args, f.Arg())
+		}
+	}
+	args = append(args, s.Image)
+	args = append(args, s.Command...)
+
+	unit := &unitFile{}
+	u := unit.section("Unit")
+	u.add("Description", name+" container")
+	u.add("After", "docker.service")
+	u.add("Requires", "docker.service")
+
+	service := unit.section("Service")
+	service.add("TimeoutStartSec", "0")
+	service.add("Restart", systemdRestart(s.Restart))
+	service.add("ExecStartPre", "-"+unitArgs([]string{dockerBinary, "rm", "-f", name}))
+	service.add("ExecStart", unitArgs(args))
+	service.add("ExecStop", unitArgs([]string{dockerBinary, "stop", name}))
+
+	unit.section("Install").add("WantedBy", "multi-user.target")
+
+	return []File{{Name: strings.ReplaceAll(name, "/", "-") + ".service", Data: unit.Bytes()}}, nil
+}
@@ -0,0 +1,64 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemd(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "foreground flags and the restart policy are dropped",
+			command: "docker run -dit --name web --restart unless-stopped -p 80:80 nginx",
+			want: []string{
+				"Restart=always\n",
+				"ExecStart=/usr/bin/docker run --name=web --publish=80:80 nginx\n",
+			},
+			notWant: []string{"--detach", "--interactive", "--tty", "--restart", "--name=web --name"},
+		},
+		{
+			name:    "restart on failure",
+			command: "docker run --name web --restart on-failure:3 nginx",
+			want:    []string{"Restart=on-failure\n"},
+		},
+		{
+			name:    "restart always",
+			command: "docker run --name web --restart always nginx",
+			want:    []string{"Restart=always\n"},
+		},
+		{
+			name:    "no restart",
+			command: "docker run --name web --restart no nginx",
+			notWant: []string{"Restart="},
+		},
+		{
+			name:    "unknown short flags keep a single dash",
+			command: "docker run --name web -x y --unknown=z --rm nginx",
+			want:    []string{"ExecStart=/usr/bin/docker run --name=web -x=y --unknown=z --rm nginx\n"},
+		},
+		{
+			name:    "specifiers and variables are escaped",
+			command: `docker run --name web -e "PS1=100% $HOME" nginx`,
+			want:    []string{`ExecStart=/usr/bin/docker run --name=web "--env=PS1=100%% $$HOME" nginx`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "systemd", tt.command)
+			unit := files["web.service"]
+			for _, want := range tt.want {
+				require.Contains(t, unit, want)
+			}
+			for _, notWant := range tt.notWant {
+				require.NotContains(t, unit, notWant)
+			}
+		})
+	}
+}
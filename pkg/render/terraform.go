@@ -0,0 +1,238 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// Terraform renders a docker_container resource of the kreuzwerker/docker provider
+// with docker_volume and docker_network resources for the named volumes and networks.
+// Memory sizes are in MB as the provider expects.
+func Terraform(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	name := terraformName(s.Name)
+	containerName := s.ContainerName
+	if containerName == "" {
+		containerName = s.Name
+	}
+
+	var resources []*hclBlock
+	c := &hclBlock{typ: "resource", labels: []string{"docker_container", name}}
+	c.attr("name", containerName)
+	c.attr("image", s.Image)
+	if s.Entrypoint != "" {
+		c.attr("entrypoint", []string{s.Entrypoint})
+	}
+	c.attr("command", s.Command)
+	c.attr("hostname", s.Hostname)
+	c.attr("domainname", s.Domainname)
+	c.attr("user", s.User)
+	c.attr("working_dir", s.WorkingDir)
+	c.attr("env", envList(s.Environment))
+	c.attr("privileged", s.Privileged)
+	c.attr("read_only", s.ReadOnly)
+	c.attr("init", s.Init)
+	c.attr("stdin_open", s.StdinOpen)
+	c.attr("tty", s.Tty)
+	for _, f := range p.Flags() {
+		if f.Name == "rm" && f.Value == "true" {
+			c.attr("rm", true)
+		}
+	}
+	if s.Restart != "" {
+		policy, count, _ := strings.Cut(s.Restart, ":")
+		c.attr("restart", policy)
+		if count != "" {
+			retries, err := strconv.Atoi(count)
+			if err != nil {
+				return nil, fmt.Errorf("invalid restart policy %q", s.Restart)
+			}
+			c.attr("max_retry_count", retries)
+		}
+	}
+	c.attr("dns", s.DNS)
+	c.attr("dns_search", s.DNSSearch)
+	c.attr("dns_opts", s.DNSOpt)
+	c.attr("group_add", s.GroupAdd)
+	c.attr("security_opts", s.SecurityOpt)
+	c.attr("links", s.Links)
+	c.attr("cpu_shares", s.CPUShares)
+	c.attr("cpu_set", s.Cpuset)
+	c.attr("ipc_mode", s.Ipc)
+	c.attr("pid_mode", s.Pid)
+	c.attr("userns_mode", s.UsernsMode)
+	c.attr("runtime", s.Runtime)
+	c.attr("stop_signal", s.StopSignal)
+	if timeout, ok, err := stopTimeout(s); err != nil {
+		return nil, err
+	} else if ok {
+		c.attr("stop_timeout", int(timeout.Seconds()))
+	}
+	if s.ShmSize != "" {
+		size, err := parseBytes(s.ShmSize)
+		if err != nil {
+			return nil, err
+		}
+		c.attr("shm_size", mebibytes(size))
+	}
+	r, err := serviceResources(s)
+	if err != nil {
+		return nil, err
+	}
+	c.attr("memory", mebibytes(r.Memory))
+	switch {
+	case r.MemorySwap == -1:
+		c.set("memory_swap", r.MemorySwap)
+	case r.MemorySwap > 0:
+		c.set("memory_swap", mebibytes(r.MemorySwap))
+	}
+	c.attr("sysctls", s.Sysctls)
+	if s.Logging != nil {
+		c.attr("log_driver", s.Logging.Driver)
+		c.attr("log_opts", s.Logging.Options)
+	}
+	mounts := serviceMounts(s)
+	tmpfs := parser.Mapping{}
+	for _, m := range mounts {
+		if m.Type == tmpfsMount {
+			tmpfs.Set(m.Target, m.Options)
+		}
+	}
+	c.attr("tmpfs", tmpfs)
+
+	switch mode := s.NetworkMode; {
+	case mode == "":
+	case containsString(dockerNetworkModes, mode) || strings.HasPrefix(mode, "container:"):
+		c.attr("network_mode", mode)
+	default:
+		network := &hclBlock{typ: "resource", labels: []string{"docker_network", terraformName(mode)}}
+		network.attr("name", mode)
+		resources = append(resources, network)
+
+		advanced := c.block("networks_advanced")
+		advanced.attr("name", hclExpression("docker_network."+terraformName(mode)+".name"))
+		if n := s.Networks["default"]; n != nil {
+			advanced.attr("aliases", n.Aliases)
+			advanced.attr("ipv4_address", n.Ipv4Address)
+			advanced.attr("ipv6_address", n.Ipv6Address)
+		}
+	}
+
+	ports, err := parsePorts(portSpecs(s.Ports))
+	if err != nil {
+		return nil, err
+	}
+	for _, port := range ports {
+		block := c.block("ports")
+		block.attr("internal", port.ContainerPort)
+		block.attr("external", port.HostPort)
+		block.attr("ip", port.HostIP)
+		if port.Protocol != "tcp" {
+			block.attr("protocol", port.Protocol)
+		}
+	}
+
+	for _, m := range mounts {
+		if m.Type == tmpfsMount {
+			continue
+		}
+		volume := c.block("volumes")
+		switch {
+		case m.Type == bindMount:
+			volume.attr("host_path", m.Source)
+		case m.Source != "":
+			volumeName := terraformName(m.Source)
+			if !terraformHasResource(resources, "docker_volume", volumeName) {
+				resource := &hclBlock{typ: "resource", labels: []string{"docker_volume", volumeName}}
+				resource.attr("name", m.Source)
+				resources = append(resources, resource)
+			}
+			volume.attr("volume_name", hclExpression("docker_volume."+volumeName+".name"))
+		}
+		volume.attr("container_path", m.Target)
+		volume.attr("read_only", m.ReadOnly)
+	}
+
+	if h := s.Healthcheck; h != nil && h.Test != "" && !h.Disable {
+		health := c.block("healthcheck")
+		health.attr("test", []string{"CMD-SHELL", h.Test})
+		health.attr("interval", h.Interval)
+		health.attr("timeout", h.Timeout)
+		health.attr("retries", h.Retries)
+		health.attr("start_period", h.StartPeriod)
+	}
+	if len(s.CapAdd) > 0 || len(s.CapDrop) > 0 {
+		capabilities := c.block("capabilities")
+		capabilities.attr("add", s.CapAdd)
+		capabilities.attr("drop", s.CapDrop)
+	}
+	for _, u := range s.Ulimits {
+		ulimit := c.block("ulimit")
+		ulimit.attr("name", u.Name)
+		ulimit.set("soft", u.Soft)
+		ulimit.set("hard", u.Hard)
+	}
+	for _, kv := range s.Labels {
+		label := c.block("labels")
+		label.attr("label", kv.Key)
+		label.set("value", kv.Value)
+	}
+	for _, h := range s.ExtraHosts {
+		hostname, ip, _ := strings.Cut(h, ":")
+		host := c.block("host")
+		host.attr("host", hostname)
+		host.attr("ip", ip)
+	}
+	for _, d := range s.Devices {
+		parts := strings.Split(d, ":")
+		device := c.block("devices")
+		device.attr("host_path", parts[0])
+		if len(parts) > 1 {
+			device.attr("container_path", parts[1])
+		}
+		if len(parts) > 2 {
+			device.attr("permissions", parts[2])
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, block := range append(resources, c) {
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.Write(block.Bytes())
+	}
+	return []File{{Name: name + ".tf", Data: buf.Bytes()}}, nil
+}
+
+// terraformName converts a name into a terraform resource name.
+func terraformName(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_', r == '-', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+func terraformHasResource(resources []*hclBlock, typ, name string) bool {
+	for _, r := range resources {
+		if r.labels[0] == typ && r.labels[1] == name {
+			return true
+		}
+	}
+	return false
+}
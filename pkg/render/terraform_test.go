@@ -0,0 +1,95 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTerraform(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "restart on failure with a retry count",
+			command: "docker run --restart on-failure:5 nginx",
+			want:    []string{"  restart         = \"on-failure\"\n  max_retry_count = 5\n"},
+		},
+		{
+			name:    "restart without a retry count",
+			command: "docker run --restart unless-stopped nginx",
+			want:    []string{"  restart = \"unless-stopped\"\n"},
+			notWant: []string{"max_retry_count"},
+		},
+		{
+			name:    "anonymous, named and host volumes",
+			command: "docker run -v /anon -v data:/data -v /srv:/srv:ro nginx",
+			want: []string{`
+  volumes {
+    container_path = "/anon"
+  }
+`, `
+  volumes {
+    volume_name    = docker_volume.data.name
+    container_path = "/data"
+  }
+`, `
+  volumes {
+    host_path      = "/srv"
+    container_path = "/srv"
+    read_only      = true
+  }
+`, `resource "docker_volume" "data" {
+  name = "data"
+}
+`},
+			notWant: []string{`resource "docker_volume" "anon"`},
+		},
+		{
+			name:    "port protocols",
+			command: "docker run -p 8080:80 -p 53:53/udp nginx",
+			want: []string{`
+  ports {
+    internal = 80
+    external = 8080
+  }
+`, `
+  ports {
+    internal = 53
+    external = 53
+    protocol = "udp"
+  }
+`},
+		},
+		{
+			name:    "host IPs and unpublished ports",
+			command: "docker run -p 127.0.0.1:9000:9000 -p 7000 nginx",
+			want: []string{`
+  ports {
+    internal = 9000
+    external = 9000
+    ip       = "127.0.0.1"
+  }
+`, `
+  ports {
+    internal = 7000
+  }
+`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := renderFiles(t, "terraform", tt.command)["nginx.tf"]
+			for _, want := range tt.want {
+				require.Contains(t, config, want)
+			}
+			for _, notWant := range tt.notWant {
+				require.NotContains(t, config, notWant)
+			}
+		})
+	}
+}
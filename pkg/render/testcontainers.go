@@ -0,0 +1,330 @@
+package render
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/profclems/compozify/pkg/parser"
+)
+
+// dockerNetworkModes are the network modes which are not the name of a user defined network.
+var dockerNetworkModes = []string{"bridge", "default", "host", "none"}
+
+// goImportNames are the names of imported packages which differ from the last element of their path.
+var goImportNames = map[string]string{
+	"github.com/docker/go-units": "units",
+}
+
+// TestcontainersGo renders a Go function which starts the container with testcontainers-go.
+// Ports are exposed on random host ports as tests look them up with MappedPort.
+// The health check is set on the container config and waited for with wait.ForHealthCheck.
+// Bind mounts are added to the host config binds, named volumes are container mounts.
+// Anonymous volumes are left out as testcontainers requires a volume name.
+func TestcontainersGo(p *parser.Parser) ([]File, error) {
+	s := p.Service()
+	imports := map[string]bool{
+		"context": true,
+		"github.com/testcontainers/testcontainers-go": true,
+	}
+
+	var req goBlock
+	req.field("Image", goString(s.Image))
+	if s.ContainerName != "" {
+		req.field("Name", goString(s.ContainerName))
+	}
+	ports, err := servicePorts(s)
+	if err != nil {
+		return nil, err
+	}
+	var exposed []string
+	for _, port := range ports {
+		exposed = appendUnique(exposed, fmt.Sprintf("%d/%s", port.ContainerPort, port.Protocol))
+	}
+	req.strings("ExposedPorts", exposed)
+	req.stringMap("Env", definedEnv(s.Environment))
+	if s.Entrypoint != "" {
+		req.strings("Entrypoint", []string{s.Entrypoint})
+	}
+	req.strings("Cmd", s.Command)
+	if s.User != "" {
+		req.field("User", goString(s.User))
+	}
+	if s.WorkingDir != "" {
+		req.field("WorkingDir", goString(s.WorkingDir))
+	}
+	if s.Privileged {
+		req.field("Privileged", "true")
+	}
+	req.stringMap("Labels", s.Labels)
+	if s.NetworkMode != "" {
+		if containsString(dockerNetworkModes, s.NetworkMode) || strings.HasPrefix(s.NetworkMode, "container:") {
+			req.field("NetworkMode", "container.NetworkMode("+goString(s.NetworkMode)+")")
+			imports["github.com/docker/docker/api/types/container"] = true
+		} else {
+			req.strings("Networks", []string{s.NetworkMode})
+		}
+	}
+	if n := s.Networks["default"]; n != nil && len(n.Aliases) > 0 {
+		network := s.NetworkMode
+		if network == "" {
+			network = "default"
+		}
+		req.field("NetworkAliases", fmt.Sprintf("map[string][]string{%s: %s}", goString(network), goStrings(n.Aliases)))
+	}
+
+	var mounts, binds []string
+	tmpfs := parser.Mapping{}
+	for _, m := range serviceMounts(s) {
+		switch {
+		case m.Type == tmpfsMount:
+			tmpfs.Set(m.Target, m.Options)
+		case m.Type == bindMount:
+			bind := m.Source + ":" + m.Target
+			if m.ReadOnly {
+				bind += ":ro"
+			}
+			binds = append(binds, goString(bind))
+		case m.Source == "":
+			// an anonymous volume, the container writes to its own filesystem instead
+		default:
+			mounts = append(mounts, fmt.Sprintf("{Source: testcontainers.GenericVolumeMountSource{Name: %s}, Target: %s, ReadOnly: %t}",
+				goString(m.Source), goString(m.Target), m.ReadOnly))
+		}
+	}
+	if len(mounts) > 0 {
+		req.field("Mounts", "testcontainers.ContainerMounts{\n"+strings.Join(mounts, ",\n")+",\n}")
+	}
+	req.stringMap("Tmpfs", tmpfs)
+
+	var config goBlock
+	if s.Hostname != "" {
+		config.line("config.Hostname = " + goString(s.Hostname))
+	}
+	if h := s.Healthcheck; h != nil && h.Test != "" && !h.Disable {
+		var health goBlock
+		health.strings("Test", []string{"CMD-SHELL", h.Test})
+		durations, err := parseHealthDurations(h)
+		if err != nil {
+			return nil, err
+		}
+		for _, d := range []struct {
+			name     string
+			duration time.Duration
+		}{{"Interval", durations.Interval}, {"Timeout", durations.Timeout}, {"StartPeriod", durations.StartPeriod}} {
+			if d.duration == 0 {
+				continue
+			}
+			health.field(d.name, goDuration(d.duration))
+			imports["time"] = true
+		}
+		if h.Retries > 0 {
+			health.field("Retries", fmt.Sprint(h.Retries))
+		}
+		config.line("config.Healthcheck = &container.HealthConfig{\n" + health.String() + "}")
+		req.field("WaitingFor", "wait.ForHealthCheck()")
+		imports["github.com/testcontainers/testcontainers-go/wait"] = true
+	}
+	if config.Len() > 0 {
+		req.field("ConfigModifier", "func(config *container.Config) {\n"+config.String()+"}")
+		imports["github.com/docker/docker/api/types/container"] = true
+	}
+
+	var host goBlock
+	if len(binds) > 0 {
+		host.line("hostConfig.Binds = append(hostConfig.Binds, " + strings.Join(binds, ", ") + ")")
+	}
+	if len(s.CapAdd) > 0 {
+		host.line("hostConfig.CapAdd = " + goStrings(s.CapAdd))
+	}
+	if len(s.CapDrop) > 0 {
+		host.line("hostConfig.CapDrop = " + goStrings(s.CapDrop))
+	}
+	if len(s.ExtraHosts) > 0 {
+		host.line("hostConfig.ExtraHosts = " + goStrings(s.ExtraHosts))
+	}
+	if len(s.DNS) > 0 {
+		host.line("hostConfig.DNS = " + goStrings(s.DNS))
+	}
+	if len(s.SecurityOpt) > 0 {
+		host.line("hostConfig.SecurityOpt = " + goStrings(s.SecurityOpt))
+	}
+	if s.ReadOnly {
+		host.line("hostConfig.ReadonlyRootfs = true")
+	}
+	if s.Init {
+		host.line("hostConfig.Init = &[]bool{true}[0]")
+	}
+	if len(s.Sysctls) > 0 {
+		host.line("hostConfig.Sysctls = " + goMap(s.Sysctls))
+	}
+	if s.ShmSize != "" {
+		size, err := parseBytes(s.ShmSize)
+		if err != nil {
+			return nil, err
+		}
+		host.line(fmt.Sprintf("hostConfig.ShmSize = %d", size))
+	}
+	r, err := serviceResources(s)
+	if err != nil {
+		return nil, err
+	}
+	if r.CPUs > 0 {
+		host.line(fmt.Sprintf("hostConfig.NanoCPUs = %d", int64(math.Round(r.CPUs*1e9))))
+	}
+	if r.Memory > 0 {
+		host.line(fmt.Sprintf("hostConfig.Memory = %d", r.Memory))
+	}
+	if len(s.Ulimits) > 0 {
+		var ulimits []string
+		for _, u := range s.Ulimits {
+			ulimits = append(ulimits, fmt.Sprintf("{Name: %s, Soft: %d, Hard: %d}", goString(u.Name), u.Soft, u.Hard))
+		}
+		host.line("hostConfig.Ulimits = []*units.Ulimit{\n" + strings.Join(ulimits, ",\n") + ",\n}")
+		imports["github.com/docker/go-units"] = true
+	}
+	if host.Len() > 0 {
+		req.field("HostConfigModifier", "func(hostConfig *container.HostConfig) {\n"+host.String()+"}")
+		imports["github.com/docker/docker/api/types/container"] = true
+	}
+
+	name := goIdentifier(s.Name)
+	var buf bytes.Buffer
+	buf.WriteString("package containers\n\nimport (\n")
+	for _, imp := range goImports(imports) {
+		buf.WriteString(imp + "\n")
+	}
+	buf.WriteString(")\n\n")
+	fmt.Fprintf(&buf, "// new%sContainer starts the %s container.\n", name, s.Name)
+	fmt.Fprintf(&buf, "func new%sContainer(ctx context.Context) (testcontainers.Container, error) {\n", name)
+	buf.WriteString("req := testcontainers.ContainerRequest{\n" + req.String() + "}\n\n")
+	buf.WriteString(`return testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+ContainerRequest: req,
+Started: true,
+})
+}
+`)
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return []File{{Name: strings.ReplaceAll(dnsName(s.Name), "-", "_") + "_container.go", Data: src}}, nil
+}
+
+// goBlock collects struct fields or statements of generated Go code.
+type goBlock struct {
+	bytes.Buffer
+}
+
+func (b *goBlock) field(name, value string) {
+	b.WriteString(name + ": " + value + ",\n")
+}
+
+func (b *goBlock) line(s string) {
+	b.WriteString(s + "\n")
+}
+
+func (b *goBlock) strings(name string, values []string) {
+	if len(values) > 0 {
+		b.field(name, goStrings(values))
+	}
+}
+
+func (b *goBlock) stringMap(name string, m parser.Mapping) {
+	if len(m) > 0 {
+		b.field(name, goMap(m))
+	}
+}
+
+func goString(s string) string {
+	return fmt.Sprintf("%q", s)
+}
+
+func goStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = goString(v)
+	}
+	return "[]string{" + strings.Join(quoted, ", ") + "}"
+}
+
+func goMap(m parser.Mapping) string {
+	var b strings.Builder
+	b.WriteString("map[string]string{\n")
+	for _, kv := range m {
+		b.WriteString(goString(kv.Key) + ": " + goString(kv.Value) + ",\n")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// goDuration returns a Go expression for d, eg: 30 * time.Second.
+func goDuration(d time.Duration) string {
+	for _, unit := range []struct {
+		duration time.Duration
+		name     string
+	}{
+		{time.Hour, "time.Hour"},
+		{time.Minute, "time.Minute"},
+		{time.Second, "time.Second"},
+		{time.Millisecond, "time.Millisecond"},
+	} {
+		if d%unit.duration == 0 {
+			return fmt.Sprintf("%d * %s", d/unit.duration, unit.name)
+		}
+	}
+	return fmt.Sprintf("%d", d)
+}
+
+// goIdentifier converts a service name into an exported Go identifier, eg: my-db becomes MyDb.
+func goIdentifier(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	if b.Len() == 0 || !unicode.IsLetter([]rune(b.String())[0]) {
+		return "Service" + b.String()
+	}
+	return b.String()
+}
+
+// goImports returns the import specs grouped into standard library and third party packages.
+func goImports(imports map[string]bool) []string {
+	paths := make([]string, 0, len(imports))
+	for imp := range imports {
+		paths = append(paths, imp)
+	}
+	sort.Strings(paths)
+
+	var std, other []string
+	for _, path := range paths {
+		spec := goString(path)
+		if name, ok := goImportNames[path]; ok {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.SplitN(path, "/", 2)[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	if len(std) > 0 && len(other) > 0 {
+		std = append(std, "")
+	}
+	return append(std, other...)
+}
@@ -0,0 +1,65 @@
+package render
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTestcontainersGo(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		want    []string
+		notWant []string
+	}{
+		{
+			name: "host config",
+			command: "docker run --name db --network host --init --read-only --shm-size 64m --sysctl net.core.somaxconn=1024 " +
+				"--add-host db.local:10.0.0.2 --dns 8.8.8.8 --security-opt no-new-privileges --health-cmd pg_isready " +
+				"--health-start-period 1m --privileged postgres",
+			want: []string{
+				`NetworkMode: container.NetworkMode("host"),`,
+				`hostConfig.Init = &[]bool{true}[0]`,
+				`StartPeriod: 1 * time.Minute,`,
+			},
+		},
+		{
+			name:    "network aliases",
+			command: "docker run --network backend --network-alias api nginx",
+			want:    []string{`NetworkAliases: map[string][]string{"backend": []string{"api"}},`},
+		},
+		{
+			name:    "anonymous volumes are left out",
+			command: "docker run -v /cache -v data:/data nginx",
+			want:    []string{`{Source: testcontainers.GenericVolumeMountSource{Name: "data"}, Target: "/data", ReadOnly: false},`},
+			notWant: []string{`Name: ""`, `"/cache"`},
+		},
+		{
+			name:    "bind mounts are host config binds",
+			command: "docker run -v /etc/app:/etc/app:ro -v /srv/html:/usr/share/nginx/html --mount type=bind,src=/srv,dst=/srv nginx",
+			want:    []string{`hostConfig.Binds = append(hostConfig.Binds, "/etc/app:/etc/app:ro", "/srv/html:/usr/share/nginx/html", "/srv:/srv")`},
+			notWant: []string{"GenericBindMountSource", "Mounts"},
+		},
+		{
+			name:    "only anonymous volumes",
+			command: "docker run -v /cache nginx",
+			notWant: []string{"Mounts"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files := renderFiles(t, "testcontainers-go", tt.command)
+			require.Len(t, files, 1)
+			for _, src := range files {
+				for _, want := range tt.want {
+					require.Contains(t, src, want)
+				}
+				for _, notWant := range tt.notWant {
+					require.NotContains(t, src, notWant)
+				}
+			}
+		})
+	}
+}
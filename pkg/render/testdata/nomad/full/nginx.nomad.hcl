@@ -0,0 +1,99 @@
+job "nginx" {
+  datacenters = ["dc1"]
+  type        = "service"
+
+  group "nginx" {
+    count = 1
+
+    network {
+      port "p80" {
+        static = 8080
+        to     = 80
+      }
+
+      port "p443" {
+        static = 8443
+        to     = 443
+      }
+    }
+
+    restart {
+      mode = "delay"
+    }
+
+    task "nginx" {
+      driver = "docker"
+      user   = "1000:1000"
+
+      config {
+        image        = "nginx:1.25"
+        entrypoint   = ["/docker-entrypoint.sh"]
+        command      = "nginx"
+        args         = ["-g", "daemon off;"]
+        ports        = ["p80", "p443"]
+        hostname     = "web.local"
+        work_dir     = "/app"
+        network_mode = "backend"
+        cap_add      = ["NET_ADMIN"]
+        cap_drop     = ["ALL"]
+        labels       = { "com.example.team" = "web" }
+        ulimit       = { nofile = "1024:2048" }
+
+        logging {
+          type   = "json-file"
+          config = [{ max-size = "10m" }]
+        }
+
+        mount {
+          type   = "volume"
+          source = "data"
+          target = "/var/lib/data"
+        }
+
+        mount {
+          type     = "bind"
+          source   = "/etc/app"
+          target   = "/etc/app"
+          readonly = true
+        }
+
+        mount {
+          type   = "tmpfs"
+          target = "/run"
+
+          tmpfs_options {
+            size = 67108864
+          }
+        }
+      }
+
+      env = {
+        APP_ENV     = "production"
+        DB_PASSWORD = "secret"
+      }
+
+      resources {
+        cpu        = 500
+        memory     = 256
+        memory_max = 512
+      }
+
+      service {
+        name = "nginx"
+        port = "p80"
+
+        check {
+          type     = "script"
+          command  = "/bin/sh"
+          args     = ["-c", "curl -f http://localhost/ || exit 1"]
+          interval = "30s"
+          timeout  = "5s"
+
+          check_restart {
+            limit = 3
+          }
+        }
+      }
+    }
+  }
+}
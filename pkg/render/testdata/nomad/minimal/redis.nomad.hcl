@@ -0,0 +1,21 @@
+job "redis" {
+  datacenters = ["dc1"]
+  type        = "service"
+
+  group "redis" {
+    count = 1
+
+    restart {
+      attempts = 0
+      mode     = "fail"
+    }
+
+    task "redis" {
+      driver = "docker"
+
+      config {
+        image = "redis"
+      }
+    }
+  }
+}
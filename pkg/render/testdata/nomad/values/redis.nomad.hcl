@@ -0,0 +1,29 @@
+job "redis" {
+  datacenters = ["dc1"]
+  type        = "service"
+
+  group "redis" {
+    count = 1
+
+    restart {
+      attempts = 0
+      mode     = "fail"
+    }
+
+    task "redis" {
+      driver = "docker"
+
+      config {
+        image  = "redis"
+        labels = { version = "2" }
+      }
+
+      env = {
+        PORT  = "8080"
+        DEBUG = "true"
+        RATIO = "1.5"
+        N     = "08"
+      }
+    }
+  }
+}
@@ -0,0 +1,72 @@
+resource "docker_network" "backend" {
+  name = "backend"
+}
+
+resource "docker_volume" "data" {
+  name = "data"
+}
+
+resource "docker_container" "nginx" {
+  name        = "web"
+  image       = "nginx:1.25"
+  entrypoint  = ["/docker-entrypoint.sh"]
+  command     = ["nginx", "-g", "daemon off;"]
+  hostname    = "web.local"
+  user        = "1000:1000"
+  working_dir = "/app"
+  env         = ["APP_ENV=production", "DB_PASSWORD=secret"]
+  restart     = "unless-stopped"
+  memory      = 512
+  log_driver  = "json-file"
+  log_opts    = { max-size = "10m" }
+  tmpfs       = { "/run" = "size=64m" }
+
+  networks_advanced {
+    name = docker_network.backend.name
+  }
+
+  ports {
+    internal = 80
+    external = 8080
+  }
+
+  ports {
+    internal = 443
+    external = 8443
+    ip       = "127.0.0.1"
+  }
+
+  volumes {
+    volume_name    = docker_volume.data.name
+    container_path = "/var/lib/data"
+  }
+
+  volumes {
+    host_path      = "/etc/app"
+    container_path = "/etc/app"
+    read_only      = true
+  }
+
+  healthcheck {
+    test     = ["CMD-SHELL", "curl -f http://localhost/ || exit 1"]
+    interval = "30s"
+    timeout  = "5s"
+    retries  = 3
+  }
+
+  capabilities {
+    add  = ["NET_ADMIN"]
+    drop = ["ALL"]
+  }
+
+  ulimit {
+    name = "nofile"
+    soft = 1024
+    hard = 2048
+  }
+
+  labels {
+    label = "com.example.team"
+    value = "web"
+  }
+}
@@ -0,0 +1,4 @@
+resource "docker_container" "redis" {
+  name  = "redis"
+  image = "redis"
+}
@@ -0,0 +1,10 @@
+resource "docker_container" "redis" {
+  name  = "redis"
+  image = "redis"
+  env   = ["PORT=8080", "DEBUG=true", "RATIO=1.5", "N=08"]
+
+  labels {
+    label = "version"
+    value = "2"
+  }
+}
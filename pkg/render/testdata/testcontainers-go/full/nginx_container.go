@@ -0,0 +1,63 @@
+package containers
+
+import (
+	"context"
+	"time"
+
+	"github.com/docker/docker/api/types/container"
+	units "github.com/docker/go-units"
+	"github.com/testcontainers/testcontainers-go"
+	"github.com/testcontainers/testcontainers-go/wait"
+)
+
+// newNginxContainer starts the nginx container.
+func newNginxContainer(ctx context.Context) (testcontainers.Container, error) {
+	req := testcontainers.ContainerRequest{
+		Image:        "nginx:1.25",
+		Name:         "web",
+		ExposedPorts: []string{"80/tcp", "443/tcp", "9000/tcp"},
+		Env: map[string]string{
+			"APP_ENV":     "production",
+			"DB_PASSWORD": "secret",
+		},
+		Entrypoint: []string{"/docker-entrypoint.sh"},
+		Cmd:        []string{"nginx", "-g", "daemon off;"},
+		User:       "1000:1000",
+		WorkingDir: "/app",
+		Labels: map[string]string{
+			"com.example.team": "web",
+		},
+		Networks: []string{"backend"},
+		Mounts: testcontainers.ContainerMounts{
+			{Source: testcontainers.GenericVolumeMountSource{Name: "data"}, Target: "/var/lib/data", ReadOnly: false},
+		},
+		Tmpfs: map[string]string{
+			"/run": "size=64m",
+		},
+		WaitingFor: wait.ForHealthCheck(),
+		ConfigModifier: func(config *container.Config) {
+			config.Hostname = "web.local"
+			config.Healthcheck = &container.HealthConfig{
+				Test:     []string{"CMD-SHELL", "curl -f http://localhost/ || exit 1"},
+				Interval: 30 * time.Second,
+				Timeout:  5 * time.Second,
+				Retries:  3,
+			}
+		},
+		HostConfigModifier: func(hostConfig *container.HostConfig) {
+			hostConfig.Binds = append(hostConfig.Binds, "/etc/app:/etc/app:ro")
+			hostConfig.CapAdd = []string{"NET_ADMIN"}
+			hostConfig.CapDrop = []string{"ALL"}
+			hostConfig.NanoCPUs = 500000000
+			hostConfig.Memory = 536870912
+			hostConfig.Ulimits = []*units.Ulimit{
+				{Name: "nofile", Soft: 1024, Hard: 2048},
+			}
+		},
+	}
+
+	return testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+}
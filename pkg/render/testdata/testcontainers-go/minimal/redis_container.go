@@ -0,0 +1,19 @@
+package containers
+
+import (
+	"context"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// newRedisContainer starts the redis container.
+func newRedisContainer(ctx context.Context) (testcontainers.Container, error) {
+	req := testcontainers.ContainerRequest{
+		Image: "redis",
+	}
+
+	return testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+}
@@ -0,0 +1,28 @@
+package containers
+
+import (
+	"context"
+
+	"github.com/testcontainers/testcontainers-go"
+)
+
+// newRedisContainer starts the redis container.
+func newRedisContainer(ctx context.Context) (testcontainers.Container, error) {
+	req := testcontainers.ContainerRequest{
+		Image: "redis",
+		Env: map[string]string{
+			"PORT":  "8080",
+			"DEBUG": "true",
+			"RATIO": "1.5",
+			"N":     "08",
+		},
+		Labels: map[string]string{
+			"version": "2",
+		},
+	}
+
+	return testcontainers.GenericContainer(ctx, testcontainers.GenericContainerRequest{
+		ContainerRequest: req,
+		Started:          true,
+	})
+}
@@ -0,0 +1,88 @@
+package render
+
+import (
+	"bytes"
+	"strings"
+)
+
+// unitSection is a section of a systemd unit file, eg: [Service].
+type unitSection struct {
+	name    string
+	entries [][2]string
+}
+
+// add adds an entry for each non-empty value. Keys like Environment= may be repeated.
+func (s *unitSection) add(key string, values ...string) {
+	for _, v := range values {
+		if v != "" {
+			s.entries = append(s.entries, [2]string{key, v})
+		}
+	}
+}
+
+// unitFile is a systemd unit file, also used for podman quadlet units.
+type unitFile struct {
+	sections []*unitSection
+}
+
+// section returns the section with the given name, adding it if it does not exist.
+func (u *unitFile) section(name string) *unitSection {
+	for _, s := range u.sections {
+		if s.name == name {
+			return s
+		}
+	}
+	s := &unitSection{name: name}
+	u.sections = append(u.sections, s)
+	return s
+}
+
+// Bytes encodes the unit file. Sections without entries are left out.
+// Percent signs are escaped as systemd expands them as specifiers.
+func (u *unitFile) Bytes() []byte {
+	var buf bytes.Buffer
+	for _, s := range u.sections {
+		if len(s.entries) == 0 {
+			continue
+		}
+		if buf.Len() > 0 {
+			buf.WriteByte('\n')
+		}
+		buf.WriteString("[" + s.name + "]\n")
+		for _, e := range s.entries {
+			buf.WriteString(e[0] + "=" + strings.ReplaceAll(e[1], "%", "%%") + "\n")
+		}
+	}
+	return buf.Bytes()
+}
+
+// unitQuote quotes a value for the systemd command line and environment syntax
+// if it contains whitespace, quotes or backslashes.
+func unitQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// unitArgs joins command line arguments, quoting them where needed.
+// Dollar signs are escaped as systemd expands environment variables in command lines.
+func unitArgs(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = unitQuote(strings.ReplaceAll(arg, "$", "$$"))
+	}
+	return strings.Join(quoted, " ")
+}
+
+// systemdRestart converts a docker restart policy into a systemd Restart= value.
+func systemdRestart(policy string) string {
+	name, _, _ := strings.Cut(policy, ":")
+	switch name {
+	case "always", "unless-stopped":
+		return "always"
+	case "on-failure":
+		return "on-failure"
+	}
+	return ""
+}